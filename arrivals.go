@@ -0,0 +1,185 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// NHPPDistr generates the arrivals of the non-homogeneous Poisson process
+// with the rate lambda(t) by thinning:
+// the candidate arrivals are generated with the maximal rate and
+// the candidate at time t is accepted with probability lambda(t)/maxRate.
+// If the rate drops to zero and stays there, the horizon shall be set,
+// otherwise the thinning panics after nHPP_MAX_REJECTIONS rejected candidates.
+
+package godes
+
+import (
+	"math"
+)
+
+const nHPP_MAX_REJECTIONS = 10000000
+
+// RateFunction returns the arrival rate at the simulation time t
+type RateFunction func(t float64) float64
+
+// NHPPDistr represents the generator for the non-homogeneous Poisson arrivals
+type NHPPDistr struct {
+	distribution
+	rate    RateFunction
+	maxRate float64
+	// the simulation time after which the rate is zero, +Inf if none
+	horizon float64
+	sim     *Simulation
+}
+
+// NewNHPPDistr initiats the generator for the non-homogeneous Poisson arrivals.
+// maxRate shall not be less than rate(t) for any t.
+// If repetition flag is true, the generator will generate the same sequences for every execution
+func NewNHPPDistr(repetion bool, rate RateFunction, maxRate float64) *NHPPDistr {
+	return defaultSimulation.NewNHPPDistr(repetion, rate, maxRate)
+}
+
+// NewNHPPDistr initiats the generator of the simulation for the non-homogeneous Poisson arrivals
+func (sim *Simulation) NewNHPPDistr(repetion bool, rate RateFunction, maxRate float64) *NHPPDistr {
+	if rate == nil {
+		panic("rate is nil")
+	}
+	if maxRate <= 0 {
+		panic("invalid maxRate")
+	}
+	return &NHPPDistr{distribution: sim.newDistribution(repetion), rate: rate, maxRate: maxRate, horizon: math.Inf(1), sim: sim}
+}
+
+// NewNHPPDistrStream initiats the stream generator for the non-homogeneous Poisson arrivals
+func NewNHPPDistrStream(stream int, rate RateFunction, maxRate float64) *NHPPDistr {
+	return defaultSimulation.NewNHPPDistrStream(stream, rate, maxRate)
+}
+
+// NewNHPPDistrStream initiats the stream generator of the simulation for the non-homogeneous Poisson arrivals
+func (sim *Simulation) NewNHPPDistrStream(stream int, rate RateFunction, maxRate float64) *NHPPDistr {
+	if rate == nil {
+		panic("rate is nil")
+	}
+	if maxRate <= 0 {
+		panic("invalid maxRate")
+	}
+	b := &NHPPDistr{distribution: sim.streams.newDistribution(stream), rate: rate, maxRate: maxRate, horizon: math.Inf(1), sim: sim}
+	return b
+}
+
+// NewPiecewiseNHPPDistr initiats the generator for the arrivals with piecewise-constant rate.
+// rates[i] is applied from times[i] until times[i+1], the last rate is applied until the end
+// and rates[0] is applied before times[0].
+// If period is positive, the rate pattern is repeated with the period (e.g. 24 hours).
+func NewPiecewiseNHPPDistr(repetion bool, times []float64, rates []float64, period float64) *NHPPDistr {
+	return defaultSimulation.NewPiecewiseNHPPDistr(repetion, times, rates, period)
+}
+
+// NewPiecewiseNHPPDistr initiats the generator of the simulation for the arrivals with piecewise-constant rate
+func (sim *Simulation) NewPiecewiseNHPPDistr(repetion bool, times []float64, rates []float64, period float64) *NHPPDistr {
+	rate, maxRate := PiecewiseRate(times, rates, period)
+	b := sim.NewNHPPDistr(repetion, rate, maxRate)
+	b.horizon = piecewiseHorizon(times, rates, period)
+	return b
+}
+
+// NewPiecewiseNHPPDistrStream initiats the stream generator for the arrivals with piecewise-constant rate
+func NewPiecewiseNHPPDistrStream(stream int, times []float64, rates []float64, period float64) *NHPPDistr {
+	return defaultSimulation.NewPiecewiseNHPPDistrStream(stream, times, rates, period)
+}
+
+// NewPiecewiseNHPPDistrStream initiats the stream generator of the simulation for the arrivals with piecewise-constant rate
+func (sim *Simulation) NewPiecewiseNHPPDistrStream(stream int, times []float64, rates []float64, period float64) *NHPPDistr {
+	rate, maxRate := PiecewiseRate(times, rates, period)
+	b := sim.NewNHPPDistrStream(stream, rate, maxRate)
+	b.horizon = piecewiseHorizon(times, rates, period)
+	return b
+}
+
+// PiecewiseRate returns the piecewise-constant rate function and its maximal value
+func PiecewiseRate(times []float64, rates []float64, period float64) (RateFunction, float64) {
+	if len(times) == 0 || len(times) != len(rates) {
+		panic("invalid times/rates arrays")
+	}
+	maxRate := 0.
+	for i := range times {
+		if i > 0 && times[i] <= times[i-1] {
+			panic("times are not increasing")
+		}
+		if rates[i] < 0 {
+			panic("negative rate")
+		}
+		maxRate = math.Max(maxRate, rates[i])
+	}
+	if period > 0 && times[len(times)-1] >= period {
+		panic("times exceed the period")
+	}
+	if maxRate == 0 {
+		panic("all rates are zero")
+	}
+	ts := append([]float64{}, times...)
+	rs := append([]float64{}, rates...)
+	rate := func(t float64) float64 {
+		if period > 0 {
+			t = math.Mod(t, period)
+		}
+		ind := 0
+		for i := range ts {
+			if t >= ts[i] {
+				ind = i
+			} else {
+				break
+			}
+		}
+		return rs[ind]
+	}
+	return rate, maxRate
+}
+
+// SetHorizon sets the simulation time after which the rate is zero
+func (b *NHPPDistr) SetHorizon(horizon float64) *NHPPDistr {
+	b.horizon = horizon
+	return b
+}
+
+// piecewiseHorizon returns the time after which the non periodic rate is zero
+func piecewiseHorizon(times []float64, rates []float64, period float64) float64 {
+	if period <= 0 && rates[len(rates)-1] == 0 {
+		return times[len(times)-1]
+	}
+	return math.Inf(1)
+}
+
+// Next returns the time of the next arrival after the time t.
+// It returns +Inf if no arrival happens before the horizon
+func (b *NHPPDistr) Next(t float64) float64 {
+	for rejections := 0; ; rejections++ {
+		if rejections > nHPP_MAX_REJECTIONS {
+			panic("no arrival after the maximal number of rejections, the horizon is not set")
+		}
+		if t >= b.horizon {
+			return math.Inf(1)
+		}
+		t += expInverse(b.uniform()) / b.maxRate
+		r := b.rate(t)
+		if r > b.maxRate {
+			panic("rate exceeds maxRate")
+		}
+		if b.uniform()*b.maxRate < r {
+			return t
+		}
+	}
+}
+
+// Get returns the interval from the current simulation time until the next arrival.
+// It returns false if no arrival happens before the horizon
+func (b *NHPPDistr) Get() (float64, bool) {
+	stime := getSimulation(b.sim).GetSystemTime()
+	next := b.Next(stime)
+	if math.IsInf(next, 1) {
+		return 0, false
+	}
+	return next - stime, true
+}
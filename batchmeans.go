@@ -0,0 +1,139 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Batch means analysis estimates the steady-state mean from a single long run.
+// The observation series is divided into non-overlapping batches and
+// the batch means are treated as the independent observations.
+// The batch size can be selected automatically: the number of batches is halved
+// (the batch size doubled) until the lag-1 autocorrelation of the batch means
+// is not significant.
+
+package godes
+
+import (
+	"math"
+)
+
+const mIN_NUMBER_OF_BATCHES = 10
+const mAX_NUMBER_OF_BATCHES = 160
+
+// BatchMeans divides the series into the number of non-overlapping batches
+// and returns the batch means. The observations which don't fill the batch
+// are removed from the beginning of the series
+func BatchMeans(series []float64, batches int) []float64 {
+	if batches < 1 || batches > len(series) {
+		panic("invalid number of batches")
+	}
+	size := len(series) / batches
+	start := len(series) - size*batches
+	means := make([]float64, batches)
+	for i := 0; i < batches; i++ {
+		means[i] = Mean(series[start+i*size : start+(i+1)*size])
+	}
+	return means
+}
+
+// Lag1Autocorrelation returns the lag-1 autocorrelation of the sample
+func Lag1Autocorrelation(nums []float64) float64 {
+	if len(nums) < 2 {
+		return 0
+	}
+	mean := Mean(nums)
+	num := 0.
+	den := 0.
+	for i, x := range nums {
+		den += (x - mean) * (x - mean)
+		if i > 0 {
+			num += (x - mean) * (nums[i-1] - mean)
+		}
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// GetBatchCount returns the number of batches for which the lag-1 autocorrelation
+// of the batch means is not significant at 5% level.
+// The second result is false if the test did not pass with the minimal number of batches
+func GetBatchCount(series []float64) (int, bool) {
+	if len(series) < mIN_NUMBER_OF_BATCHES {
+		panic("series is too short for batch means")
+	}
+	batches := mAX_NUMBER_OF_BATCHES
+	for batches > len(series)/2 && batches > mIN_NUMBER_OF_BATCHES {
+		batches /= 2
+	}
+	z := normalInverse(0.975)
+	for {
+		r := Lag1Autocorrelation(BatchMeans(series, batches))
+		if math.Abs(r) <= z/math.Sqrt(float64(batches)) {
+			return batches, true
+		}
+		if batches/2 < mIN_NUMBER_OF_BATCHES {
+			return batches, false
+		}
+		batches /= 2
+	}
+}
+
+// BatchMeansConfidenceInterval returns the Student's t confidence interval for the steady-state mean.
+// If batches is zero, the number of batches is selected with GetBatchCount;
+// ok is false if the batch means are still correlated with the minimal number of batches
+func BatchMeansConfidenceInterval(series []float64, batches int, level float64) (lower float64, upper float64, ok bool) {
+	ok = true
+	if batches == 0 {
+		batches, ok = GetBatchCount(series)
+	}
+	lower, upper = ConfidenceInterval(BatchMeans(series, batches), level)
+	return lower, upper, ok
+}
+
+// NewBatchMeansCollector creates StatCollector with the batch means of the observations collected during
+// a single long run, one row per observation like the samples of NewStatCollector.
+// If batches is zero, the number of batches is selected automatically as the smallest
+// number required by the measures; the second result is false if the batch means
+// of any measure are still correlated with the minimal number of batches
+func NewBatchMeansCollector(measures []string, series [][]float64, batches int) (*StatCollector, bool) {
+	if measures == nil {
+		panic("null measures array")
+	}
+	if series == nil {
+		panic("null samples array")
+	}
+	columns := make([][]float64, len(measures))
+	for _, row := range series {
+		if len(row) != len(measures) {
+			panic("invalid measures/samples arrays")
+		}
+		for j, x := range row {
+			columns[j] = append(columns[j], x)
+		}
+	}
+	ok := true
+	if batches == 0 {
+		batches = mAX_NUMBER_OF_BATCHES
+		for _, column := range columns {
+			b, passed := GetBatchCount(column)
+			if b < batches {
+				batches = b
+			}
+			ok = ok && passed
+		}
+	}
+	samples := make([][]float64, batches)
+	for i := range samples {
+		samples[i] = make([]float64, len(measures))
+	}
+	for j, column := range columns {
+		for i, m := range BatchMeans(column, batches) {
+			samples[i][j] = m
+		}
+	}
+	return NewStatCollector(measures, samples), ok
+}
@@ -0,0 +1,129 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// The simulation time is measured in the time unit of the simulation
+// (minute by default). The helpers convert time.Duration to and from the simulation time.
+// If the calendar origin is set, the simulation time zero corresponds to the origin
+// and the simulation time can be reported as time.Time in the traces and reports.
+
+package godes
+
+import (
+	"fmt"
+	"time"
+)
+
+const dEFAULT_TIME_UNIT = time.Minute
+
+// calendar keeps the time unit and the calendar origin
+type calendar struct {
+	unit      time.Duration
+	origin    time.Time
+	originSet bool
+}
+
+func (c *calendar) getUnit() time.Duration {
+	if c.unit == 0 {
+		return dEFAULT_TIME_UNIT
+	}
+	return c.unit
+}
+
+// SetTimeUnit sets the duration of one unit of the simulation time of the model
+func SetTimeUnit(unit time.Duration) {
+	defaultSimulation.SetTimeUnit(unit)
+}
+
+// GetTimeUnit returns the duration of one unit of the simulation time of the model
+func GetTimeUnit() time.Duration {
+	return defaultSimulation.GetTimeUnit()
+}
+
+// SetCalendarOrigin sets the calendar time of the simulation time zero of the model
+func SetCalendarOrigin(origin time.Time) {
+	defaultSimulation.SetCalendarOrigin(origin)
+}
+
+// FromDuration converts the duration into the simulation time of the model
+func FromDuration(d time.Duration) float64 {
+	return defaultSimulation.FromDuration(d)
+}
+
+// ToDuration converts the simulation time of the model into the duration
+func ToDuration(t float64) time.Duration {
+	return defaultSimulation.ToDuration(t)
+}
+
+// GetCalendarTime returns the current simulation time of the model as the calendar time
+func GetCalendarTime() time.Time {
+	return defaultSimulation.GetCalendarTime()
+}
+
+// SetTimeUnit sets the duration of one unit of the simulation time
+func (sim *Simulation) SetTimeUnit(unit time.Duration) {
+	if unit <= 0 {
+		panic("invalid time unit")
+	}
+	sim.calendar.unit = unit
+}
+
+// GetTimeUnit returns the duration of one unit of the simulation time
+func (sim *Simulation) GetTimeUnit() time.Duration {
+	return sim.calendar.getUnit()
+}
+
+// SetCalendarOrigin sets the calendar time of the simulation time zero
+func (sim *Simulation) SetCalendarOrigin(origin time.Time) {
+	sim.calendar.origin = origin
+	sim.calendar.originSet = true
+}
+
+// GetCalendarOrigin returns the calendar origin and false if it is not set
+func (sim *Simulation) GetCalendarOrigin() (time.Time, bool) {
+	return sim.calendar.origin, sim.calendar.originSet
+}
+
+// FromDuration converts the duration into the simulation time
+func (sim *Simulation) FromDuration(d time.Duration) float64 {
+	return float64(d) / float64(sim.calendar.getUnit())
+}
+
+// ToDuration converts the simulation time into the duration
+func (sim *Simulation) ToDuration(t float64) time.Duration {
+	return time.Duration(t * float64(sim.calendar.getUnit()))
+}
+
+// CalendarTime converts the simulation time into the calendar time
+func (sim *Simulation) CalendarTime(t float64) time.Time {
+	if !sim.calendar.originSet {
+		panic("calendar origin is not set")
+	}
+	return sim.calendar.origin.Add(sim.ToDuration(t))
+}
+
+// SimulationTime converts the calendar time into the simulation time
+func (sim *Simulation) SimulationTime(c time.Time) float64 {
+	if !sim.calendar.originSet {
+		panic("calendar origin is not set")
+	}
+	return sim.FromDuration(c.Sub(sim.calendar.origin))
+}
+
+// GetCalendarTime returns the current simulation time as the calendar time
+func (sim *Simulation) GetCalendarTime() time.Time {
+	return sim.CalendarTime(sim.GetSystemTime())
+}
+
+// FormatTime returns the simulation time as the calendar time in the layout
+// if the calendar origin is set, or as the number otherwise
+func (sim *Simulation) FormatTime(t float64, layout string) string {
+	if !sim.calendar.originSet {
+		return fmt.Sprintf("%.3f", t)
+	}
+	return sim.CalendarTime(t).Format(layout)
+}
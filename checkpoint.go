@@ -0,0 +1,204 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Checkpoint is the position of the paused model which can be saved into the file
+// and restored later to resume the run or to fork it into the what-if branches.
+// The runners are goroutines, so the state of the model is restored by the replay:
+// the model is built again by the same code, the stream generators are reseeded
+// for the replication of the checkpoint and the model is executed until the same
+// number of activations. The simulation time, the event list, the random streams,
+// the queues and the statistics are then bit-for-bit identical to the original run.
+// The digest of the activations, the simulation time and the runners are verified
+// after the replay and Restore panics if the replay diverged.
+// The model shall be deterministic: the generators created with NewXxxDistr(true)
+// are seeded in the order of creation, so in the same process the stream generators
+// (NewXxxDistrStream) shall be used; the injected functions are not replayed.
+
+package godes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Checkpoint is the position of the paused model
+type Checkpoint struct {
+	// Time is the simulation time
+	Time float64 `json:"time"`
+	// Activations is the number of the runner activations before the checkpoint
+	Activations int64 `json:"activations"`
+	// Digest is the hash of the activation times and runners
+	Digest      uint64             `json:"digest"`
+	Replication int                `json:"replication"`
+	Antithetic  bool               `json:"antithetic"`
+	StreamSeed  int64              `json:"streamSeed"`
+	Runners     []CheckpointRunner `json:"runners"`
+}
+
+// CheckpointRunner is the runner of the event list at the checkpoint
+type CheckpointRunner struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name,omitempty"`
+	State       string  `json:"state"`
+	ScheduledAt float64 `json:"scheduledAt,omitempty"`
+}
+
+// journal keeps the activations of the model for the checkpoints
+type journal struct {
+	activations int64
+	digest      uint64
+	injections  int
+	// restoring is the checkpoint the model is replayed to
+	restoring *Checkpoint
+}
+
+// GetCheckpoint returns the checkpoint of the paused model
+func GetCheckpoint() *Checkpoint {
+	return defaultSimulation.GetCheckpoint()
+}
+
+// Restore replays the model until the checkpoint and pauses it
+func Restore(cp *Checkpoint) {
+	defaultSimulation.Restore(cp)
+}
+
+// GetCheckpoint returns the checkpoint of the paused simulation.
+// The simulation is paused by Step, RunUntil, Continue or Restore
+func (sim *Simulation) GetCheckpoint() *Checkpoint {
+	if sim.mdl == nil || !sim.mdl.paused {
+		panic("model is not paused")
+	}
+	mdl := sim.mdl
+	if mdl.injections > 0 {
+		panic("injected functions can not be replayed")
+	}
+	replication, antithetic := sim.GetReplication()
+	return &Checkpoint{
+		Time:        mdl.stime,
+		Activations: mdl.activations,
+		Digest:      mdl.digest,
+		Replication: replication,
+		Antithetic:  antithetic,
+		StreamSeed:  sim.streams.seed,
+		Runners:     sim.checkpointRunners(),
+	}
+}
+
+// Restore replays the simulation until the checkpoint and pauses it.
+// It is called from the main goroutine instead of WaitUntilDone, after the model
+// is built by the same code as the original run; the execution is continued by
+// Step, RunUntil, Continue or WaitUntilDone.
+// The stream generators of the simulation are reseeded with the base seed and for the replication of the checkpoint,
+// the breakpoints are not applied and the real-time mode is suspended during the replay
+func (sim *Simulation) Restore(cp *Checkpoint) {
+	if cp == nil {
+		panic("checkpoint is nil")
+	}
+	mdl := sim.debugModel()
+	if mdl.detached || mdl.activations > 0 {
+		panic("model is already executed")
+	}
+	sim.streams.seed = cp.StreamSeed
+	sim.SetReplication(cp.Replication, cp.Antithetic)
+	scale := mdl.timeScale
+	mdl.timeScale = 0
+	mdl.restoring = cp
+	mdl.stepping = false
+	paused := mdl.execute()
+	mdl.restoring = nil
+	mdl.timeScale = scale
+	if !paused || mdl.activations != cp.Activations || mdl.digest != cp.Digest || mdl.stime != cp.Time {
+		panic("replay diverged from the checkpoint")
+	}
+	runners := sim.checkpointRunners()
+	if len(runners) != len(cp.Runners) {
+		panic("replay diverged from the checkpoint")
+	}
+	for i := range runners {
+		if runners[i] != cp.Runners[i] {
+			panic(fmt.Sprintf("replay diverged from the checkpoint at runner %v", runners[i].ID))
+		}
+	}
+}
+
+// Save writes the checkpoint into the file
+func (cp *Checkpoint) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := cp.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes the checkpoint in JSON format
+func (cp *Checkpoint) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cp)
+}
+
+// LoadCheckpoint reads the checkpoint from the file
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCheckpoint(f)
+}
+
+// ReadCheckpoint reads the checkpoint in JSON format
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	cp := &Checkpoint{}
+	if err := json.NewDecoder(r).Decode(cp); err != nil {
+		return nil, fmt.Errorf("checkpoint: %v", err)
+	}
+	return cp, nil
+}
+
+func (sim *Simulation) checkpointRunners() []CheckpointRunner {
+	runners := []CheckpointRunner{}
+	for _, info := range sim.Runners() {
+		runners = append(runners, CheckpointRunner{
+			ID:          info.ID,
+			Name:        info.Name,
+			State:       info.State.String(),
+			ScheduledAt: info.ScheduledAt,
+		})
+	}
+	return runners
+}
+
+// record adds the activation of the runner into the journal
+func (mdl *model) record(runner RunnerInterface) {
+	mdl.activations++
+	mdl.digest = mix64(mdl.digest ^ math.Float64bits(mdl.stime))
+	mdl.digest = mix64(mdl.digest ^ uint64(runner.getInternalId()))
+}
+
+// restoredOnTime returns true if the replay reached the checkpoint taken before
+// the next activation at the time next; the simulation time is advanced to the checkpoint time
+func (mdl *model) restoredOnTime(next float64) bool {
+	cp := mdl.restoring
+	if cp.Activations != mdl.activations || cp.Time >= next {
+		return false
+	}
+	if cp.Time > mdl.stime {
+		from := mdl.stime
+		mdl.stime = cp.Time
+		mdl.notifyTimeAdvance(from, cp.Time)
+	}
+	return true
+}
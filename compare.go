@@ -0,0 +1,163 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Compare calculates the confidence intervals for the differences
+// between the scenarios. Each scenario is the StatCollector with one row per replication.
+// The paired-t method requires the same number of replications and is used with the
+// common random numbers, the Welch method is used for the independent scenarios.
+// When more than one interval is calculated (several measures or more than two scenarios),
+// the confidence level of each interval can be adjusted with the Bonferroni correction,
+// so all the intervals of the family hold simultaneously with the requested confidence level.
+// The family is either all the intervals or the intervals of one measure.
+
+package godes
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+)
+
+// CompareMethod is the method of the confidence interval for the difference
+type CompareMethod int
+
+// Compare methods
+const (
+	PairedT CompareMethod = iota
+	WelchT
+)
+
+// Correction is the multiple comparisons correction of the confidence level
+type Correction int
+
+// Corrections
+const (
+	// Bonferroni adjusts the level over all the intervals
+	Bonferroni Correction = iota
+	// BonferroniPerMeasure adjusts the level over the pairs of the scenarios for each measure
+	BonferroniPerMeasure
+	// NoCorrection uses the requested level for every interval
+	NoCorrection
+)
+
+// Comparison is the confidence interval for the difference of the measure between two scenarios
+type Comparison struct {
+	Measure    string
+	First      string
+	Second     string
+	Difference float64
+	Lower      float64
+	Upper      float64
+	// Level is the confidence level of the interval after the correction
+	Level float64
+}
+
+// IsSignificant returns true if the confidence interval doesn't contain zero
+func (c *Comparison) IsSignificant() bool {
+	return c.Lower > 0 || c.Upper < 0
+}
+
+// PairedConfidenceInterval returns the paired-t confidence interval for the mean of a-b
+func PairedConfidenceInterval(a []float64, b []float64, level float64) (lower float64, upper float64) {
+	if len(a) != len(b) {
+		panic("samples are not paired")
+	}
+	diff := make([]float64, len(a))
+	for i := range a {
+		diff[i] = a[i] - b[i]
+	}
+	return ConfidenceInterval(diff, level)
+}
+
+// WelchConfidenceInterval returns the Welch confidence interval for the difference of the means of a and b
+func WelchConfidenceInterval(a []float64, b []float64, level float64) (lower float64, upper float64) {
+	if level <= 0 || level >= 1 {
+		panic("invalid confidence level")
+	}
+	if len(a) < 2 || len(b) < 2 {
+		return math.NaN(), math.NaN()
+	}
+	na := float64(len(a))
+	nb := float64(len(b))
+	va := StandardDeviation(a) * StandardDeviation(a) / na
+	vb := StandardDeviation(b) * StandardDeviation(b) / nb
+	diff := Mean(a) - Mean(b)
+	if va+vb == 0 {
+		return diff, diff
+	}
+	df := (va + vb) * (va + vb) / (va*va/(na-1) + vb*vb/(nb-1))
+	hw := StudentTQuantile(1-(1-level)/2, df) * math.Sqrt(va+vb)
+	return diff - hw, diff + hw
+}
+
+// Compare returns the confidence intervals for the differences of all the measures
+// between all the pairs of the scenarios with the correction of the confidence level
+func Compare(names []string, scenarios []*StatCollector, method CompareMethod, correction Correction, level float64) []*Comparison {
+	if len(names) != len(scenarios) || len(scenarios) < 2 {
+		panic("invalid names/scenarios arrays")
+	}
+	if level <= 0 || level >= 1 {
+		panic("invalid confidence level")
+	}
+	for _, s := range scenarios[1:] {
+		if len(s.measures) != len(scenarios[0].measures) {
+			panic("scenarios have different measures")
+		}
+		if method == PairedT && len(s.samples) != len(scenarios[0].samples) {
+			panic("scenarios are not paired")
+		}
+	}
+	pairs := len(scenarios) * (len(scenarios) - 1) / 2
+	var adjusted float64
+	switch correction {
+	case Bonferroni:
+		adjusted = 1 - (1-level)/float64(pairs*len(scenarios[0].measures))
+	case BonferroniPerMeasure:
+		adjusted = 1 - (1-level)/float64(pairs)
+	case NoCorrection:
+		adjusted = level
+	default:
+		panic("unknown correction")
+	}
+	comparisons := []*Comparison{}
+	for m, measure := range scenarios[0].measures {
+		for i := 0; i < len(scenarios); i++ {
+			for j := i + 1; j < len(scenarios); j++ {
+				a := scenarios[i].getSlice(m)
+				b := scenarios[j].getSlice(m)
+				var lb, ub float64
+				switch method {
+				case PairedT:
+					lb, ub = PairedConfidenceInterval(a, b, adjusted)
+				case WelchT:
+					lb, ub = WelchConfidenceInterval(a, b, adjusted)
+				default:
+					panic("unknown compare method")
+				}
+				comparisons = append(comparisons, &Comparison{measure, names[i], names[j], Mean(a) - Mean(b), lb, ub, adjusted})
+			}
+		}
+	}
+	return comparisons
+}
+
+// PrintComparisons prints the table of the comparisons
+func PrintComparisons(comparisons []*Comparison) {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 10, 0, '\t', 0)
+	fmt.Fprintln(w, "Variable\tScenarios\tDiff.\tL-Bound\tU-Bound\tLevel\tSignificant")
+	for _, c := range comparisons {
+		significant := "no"
+		if c.IsSignificant() {
+			significant = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s - %s\t%6.3f\t%6.3f\t%6.3f\t%6.4f\t%s\n", c.Measure, c.First, c.Second, c.Difference, c.Lower, c.Upper, c.Level, significant)
+	}
+	w.Flush()
+}
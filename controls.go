@@ -32,6 +32,8 @@ package godes
 // BooleanControl is a boolean control variable
 type BooleanControl struct {
 	state bool
+	sim   *Simulation
+	onSet []func(bc *BooleanControl, state bool)
 }
 
 // NewBooleanControl constructs a BooleanControl
@@ -39,12 +41,17 @@ func NewBooleanControl() *BooleanControl {
 	return &BooleanControl{state: false}
 }
 
+// NewBooleanControl constructs a BooleanControl of the simulation
+func (sim *Simulation) NewBooleanControl() *BooleanControl {
+	return &BooleanControl{state: false, sim: sim}
+}
+
 //Wait stops the runner  untill the BooleanControll bc is set to true
 func (bc *BooleanControl) Wait(b bool) {
 	if bc.state == b {
 		//do nothing
 	} else {
-		modl.booleanControlWait(bc, b)
+		getSimulation(bc.sim).mdl.booleanControlWait(bc, b)
 	}
 }
 
@@ -53,7 +60,7 @@ func (bc *BooleanControl) WaitAndTimeout(b bool, timeOut float64) {
 	if bc.state == b {
 		//do nothing
 	} else {
-		modl.booleanControlWaitAndTimeout(bc, b, timeOut)
+		getSimulation(bc.sim).mdl.booleanControlWaitAndTimeout(bc, b, timeOut)
 	}
 }
 
@@ -64,6 +71,7 @@ func (bc *BooleanControl) Set(b bool) {
 		//do nothing
 	} else {
 		bc.state = b
+		bc.notifySet()
 	}
 }
 
@@ -74,5 +82,8 @@ func (bc *BooleanControl) GetState() bool {
 
 // Clear sets the bc value to default false
 func (bc *BooleanControl) Clear() {
-	bc.state = false
+	if bc.state {
+		bc.state = false
+		bc.notifySet()
+	}
 }
@@ -0,0 +1,253 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// The debugging functions execute the model step by step.
+// Step, RunUntil and Continue are called from the main goroutine instead of WaitUntilDone:
+// the control loop is paused before the activation of the runner and
+// the control is returned to the caller, which can inspect the model (Runners, GetSystemTime,
+// queues, statistics) and continue the execution.
+// The breakpoints pause the control loop when the simulation time reaches
+// the time of the breakpoint or when the predicate is true.
+// The arrivals shall be generated by the runners, since the main goroutine
+// does not take part in the simulation after the first call.
+
+package godes
+
+import (
+	"sort"
+)
+
+// debugger keeps the state of the step by step execution
+type debugger struct {
+	pauseChannel  chan bool
+	resumeChannel chan bool
+	// detached is true when the main goroutine left the simulation
+	detached bool
+	paused   bool
+	// next is the runner to be activated after the pause
+	next           RunnerInterface
+	stepping       bool
+	steps          int
+	breaksDisabled bool
+	breakTimes     []float64
+	breakpoints    map[int]func() bool
+	breakpointId   int
+}
+
+// Step processes exactly one activation of the runner and pauses the model.
+// It returns false if the simulation finished
+func Step() bool {
+	return defaultSimulation.Step()
+}
+
+// RunUntil executes the model until the simulation time t and pauses it.
+// The runners scheduled at t are activated. It returns false if the simulation finished
+func RunUntil(t float64) bool {
+	return defaultSimulation.RunUntil(t)
+}
+
+// Continue executes the model until the next breakpoint.
+// It returns false if the simulation finished
+func Continue() bool {
+	return defaultSimulation.Continue()
+}
+
+// BreakAt sets the breakpoint at the simulation time t
+func BreakAt(t float64) {
+	defaultSimulation.BreakAt(t)
+}
+
+// AddBreakpoint sets the breakpoint which pauses the model before the activation
+// of the runner when the predicate is true. It returns the id of the breakpoint
+func AddBreakpoint(predicate func() bool) int {
+	return defaultSimulation.AddBreakpoint(predicate)
+}
+
+// RemoveBreakpoint removes the breakpoint with the id
+func RemoveBreakpoint(id int) {
+	defaultSimulation.RemoveBreakpoint(id)
+}
+
+// IsPaused returns true if the model is paused by the debugging functions
+func IsPaused() bool {
+	return defaultSimulation.IsPaused()
+}
+
+// Step processes exactly one activation of the runner and pauses the simulation.
+// It returns false if the simulation finished
+func (sim *Simulation) Step() bool {
+	mdl := sim.debugModel()
+	mdl.stepping = true
+	mdl.steps = 1
+	return mdl.execute()
+}
+
+// RunUntil executes the simulation until the time t and pauses it.
+// The runners scheduled at t are activated. It returns false if the simulation finished
+func (sim *Simulation) RunUntil(t float64) bool {
+	mdl := sim.debugModel()
+	if t < mdl.stime {
+		panic("time is in the past")
+	}
+	mdl.addBreakTime(t)
+	mdl.stepping = false
+	return mdl.execute()
+}
+
+// Continue executes the simulation until the next breakpoint.
+// It returns false if the simulation finished
+func (sim *Simulation) Continue() bool {
+	mdl := sim.debugModel()
+	mdl.stepping = false
+	return mdl.execute()
+}
+
+// BreakAt sets the breakpoint at the simulation time t
+func (sim *Simulation) BreakAt(t float64) {
+	if sim.mdl == nil {
+		sim.createModel(nil)
+	}
+	sim.mdl.addBreakTime(t)
+}
+
+// AddBreakpoint sets the breakpoint which pauses the simulation before the activation
+// of the runner when the predicate is true. It returns the id of the breakpoint
+func (sim *Simulation) AddBreakpoint(predicate func() bool) int {
+	if predicate == nil {
+		panic("predicate is nil")
+	}
+	if sim.mdl == nil {
+		sim.createModel(nil)
+	}
+	mdl := sim.mdl
+	if mdl.breakpoints == nil {
+		mdl.breakpoints = make(map[int]func() bool)
+	}
+	mdl.breakpointId++
+	mdl.breakpoints[mdl.breakpointId] = predicate
+	return mdl.breakpointId
+}
+
+// RemoveBreakpoint removes the breakpoint with the id
+func (sim *Simulation) RemoveBreakpoint(id int) {
+	if sim.mdl == nil {
+		panic("model is nil")
+	}
+	if _, ok := sim.mdl.breakpoints[id]; !ok {
+		panic("breakpoint not found")
+	}
+	delete(sim.mdl.breakpoints, id)
+}
+
+// IsPaused returns true if the simulation is paused by the debugging functions
+func (sim *Simulation) IsPaused() bool {
+	return sim.mdl != nil && sim.mdl.paused
+}
+
+// debugModel returns the model ready for the step by step execution
+func (sim *Simulation) debugModel() *model {
+	if sim.mdl == nil || !sim.mdl.simulationActive && !sim.mdl.detached {
+		panic("model is not running")
+	}
+	return sim.mdl
+}
+
+// execute resumes the model, or starts it if the main goroutine is still in the simulation,
+// and waits until the model is paused or finished
+func (mdl *model) execute() bool {
+	select {
+	case <-mdl.done:
+		return false
+	default:
+	}
+	if mdl.paused {
+		mdl.resumeExecution()
+	} else {
+		if mdl.activeRunner.getInternalId() != 0 {
+			panic("debugging initiated for not main ball")
+		}
+		mdl.detached = true
+		mdl.removeFromMovingList(mdl.activeRunner)
+		mdl.others--
+		mdl.wakeDaemons()
+		mdl.controlChannel <- 100
+	}
+	select {
+	case <-mdl.pauseChannel:
+		return true
+	case <-mdl.done:
+		return false
+	}
+}
+
+func (mdl *model) resumeExecution() {
+	mdl.paused = false
+	mdl.resumeChannel <- true
+}
+
+// pause is called by the control loop; it returns the control to the caller
+// and waits until the execution is resumed
+func (mdl *model) pause() {
+	mdl.paused = true
+	mdl.pauseChannel <- true
+	<-mdl.resumeChannel
+}
+
+func (mdl *model) addBreakTime(t float64) {
+	mdl.breakTimes = append(mdl.breakTimes, t)
+	sort.Float64s(mdl.breakTimes)
+}
+
+// breakOnTime returns true if the breakpoint time is before the time of the next activation.
+// The simulation time is advanced to the breakpoint time
+func (mdl *model) breakOnTime(next float64) bool {
+	if !mdl.detached || mdl.breaksDisabled {
+		return false
+	}
+	if mdl.restoring != nil {
+		return mdl.restoredOnTime(next)
+	}
+	for len(mdl.breakTimes) > 0 && mdl.breakTimes[0] < mdl.stime {
+		mdl.breakTimes = mdl.breakTimes[1:]
+	}
+	if len(mdl.breakTimes) == 0 || mdl.breakTimes[0] >= next {
+		return false
+	}
+	t := mdl.breakTimes[0]
+	mdl.breakTimes = mdl.breakTimes[1:]
+	if t > mdl.stime {
+		from := mdl.stime
+		mdl.stime = t
+		mdl.notifyTimeAdvance(from, t)
+	}
+	return true
+}
+
+// breakBeforeActivation returns true if the step is completed or a predicate is true
+func (mdl *model) breakBeforeActivation() bool {
+	if !mdl.detached || mdl.breaksDisabled {
+		return false
+	}
+	if mdl.restoring != nil {
+		return mdl.restoring.Activations == mdl.activations
+	}
+	if mdl.stepping && mdl.steps <= 0 {
+		return true
+	}
+	ids := []int{}
+	for id := range mdl.breakpoints {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if mdl.breakpoints[id]() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+package main
+
+/*
+Procces Description:
+===================
+A bank opens the doors at 8:00 and closes them at 16:00.
+Two tellers work the whole day, the third teller works from 10:00 until 14:00
+to serve the midday peak and leaves in the middle of the service if the customer is not served;
+the service of such customer is resumed by the first available teller.
+The simulation is ended when the last customer has been served.
+
+Task
+====
+Execute multiple simulation runs and calculate the statistics for the
+elapsed time, queueing time and the time when the last customer leaves the bank.
+
+Model Features:
+===============
+1. Schedules
+The doors and the tellers follow godes.Schedule objects (the time unit is minute, zero is 8:00).
+The arrival source waits until the doors are open (WaitOpen) and stops when they are closed.
+
+2. Scheduled Resource
+The tellers are modeled by godes.Resource with the capacity defined by the schedule.
+The WaitWork policy suspends the service when the capacity drops and resumes it with the remaining time.
+*/
+
+import (
+	"fmt"
+
+	"github.com/agoussia/godes"
+)
+
+// Input Parameters
+const (
+	ARRIVAL_INTERVAL = 0.6
+	SERVICE_TIME     = 1.3
+	INDEPENDENT_RUNS = 20
+)
+
+var titles = []string{
+	"Elapsed Time",
+	"Queueing Time",
+	"Last Departure",
+}
+
+var doors = godes.NewSchedule(0).AddShift(0, 8*60, 1)
+var shifts = godes.NewSchedule(0).AddShift(0, 12*60, 2).AddShift(2*60, 6*60, 1)
+
+var arrival *godes.ExpDistr
+var service *godes.ExpDistr
+var tellers *godes.Resource
+var replicationStats [][]float64
+var lastDeparture float64
+
+// the Customer is a Runner
+type Customer struct {
+	*godes.Runner
+}
+
+func (customer *Customer) Run() {
+	a0 := godes.GetSystemTime()
+	tellers.Acquire(customer)
+	a1 := godes.GetSystemTime()
+	tellers.Work(customer, service.Get(1./SERVICE_TIME))
+	tellers.Release(customer)
+	a2 := godes.GetSystemTime()
+	lastDeparture = a2
+	replicationStats = append(replicationStats, []float64{a2 - a0, a1 - a0})
+}
+
+// the Source generates the customers while the doors are open
+type Source struct {
+	*godes.Runner
+}
+
+func (source *Source) Run() {
+	for godes.WaitOpen(doors) {
+		godes.AddRunner(&Customer{&godes.Runner{}})
+		godes.Advance(arrival.Get(1. / ARRIVAL_INTERVAL))
+	}
+}
+
+func replication(run int) []float64 {
+	arrival = godes.NewExpDistrStream(1)
+	service = godes.NewExpDistrStream(2)
+	tellers = godes.NewScheduledResource("tellers", shifts, godes.WaitWork)
+	replicationStats = [][]float64{}
+	godes.Run()
+	godes.AddRunner(&Source{&godes.Runner{}})
+	godes.WaitUntilDone()
+	godes.Clear()
+	replicationCollector := godes.NewStatCollector(titles[:2], replicationStats)
+	return []float64{
+		replicationCollector.GetAverage(0),
+		replicationCollector.GetAverage(1),
+		lastDeparture,
+	}
+}
+
+func main() {
+	collector := godes.Replicate(INDEPENDENT_RUNS, titles, replication)
+	collector.PrintStat()
+	fmt.Printf("Finished \n")
+}
+
+/* OUTPUT
+Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum	Half-W	Rel.Prec
+Elapsed Time	20	 6.984	 2.340	 5.889	 8.080	 4.368	12.501	 1.095	 0.157
+Queueing Time	20	 5.704	 2.331	 4.613	 6.796	 3.144	11.266	 1.091	 0.191
+Last Departure	20	496.394	 9.340	492.023	500.765	482.442	515.803	 4.371	 0.009
+Finished 
+*/
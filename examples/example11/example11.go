@@ -0,0 +1,113 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+package main
+
+/*
+Procces Description:
+===================
+A workshop has 10 identical machines that are running continuously for 4 weeks.
+Each machine makes parts; the processing time is normally distributed.
+Each machine breaks down periodically, the time to failure is exponentially distributed
+and measured in the working time of the machine. The workshop has one repairman,
+the broken machine waits until the repairman is available.
+The repair time is normally distributed; the part in progress is finished after the repair.
+
+Task
+====
+Calculate the number of the parts made by each machine and
+the availability, MTBF and MTTR of the machines.
+
+Model Features:
+===============
+1. Resources
+The machines and the repairman are modeled by godes.Resource.
+The WaitWork policy suspends the processing of the part when the machine breaks down.
+
+2. Failures
+The breakdowns are modeled by godes.Failure with the repairman as the repair crew
+(compare with example4 where the breakdowns are hand-coded).
+*/
+
+import (
+	"fmt"
+
+	"github.com/agoussia/godes"
+)
+
+const PT_MEAN = 10.0          //	Avg. processing time in minutes
+const PT_SIGMA = 2.0          //	Sigma of processing time
+const MTTF = 300.0            // 	Mean time to failure in minutes
+const REPAIR_TIME = 30.0      //	Time it takes to repair a machine in minutes
+const REPAIR_TIME_SIGMA = 1.0 //	Sigma of repair time
+
+const NUM_MACHINES = 10
+const SHUT_DOWN_TIME = 4 * 7 * 24 * 60
+
+var processingGen *godes.NormalDistr = godes.NewNormalDistr(true)
+var breaksGen *godes.ExpDistr = godes.NewExpDistr(true)
+var repairGen *godes.NormalDistr = godes.NewNormalDistr(true)
+
+var repairman *godes.Resource = godes.NewResource("Repairman", 1)
+
+type Machine struct {
+	*godes.Runner
+	resource   *godes.Resource
+	partsCount int
+}
+
+func (machine *Machine) Run() {
+	machine.resource.Acquire(machine)
+	for godes.GetSystemTime() <= SHUT_DOWN_TIME {
+		machine.resource.Work(machine, processingGen.Get(PT_MEAN, PT_SIGMA))
+		machine.partsCount++
+	}
+	machine.resource.Release(machine)
+}
+
+func main() {
+	godes.Run()
+	machines := []*Machine{}
+	failures := []*godes.Failure{}
+	for i := 0; i < NUM_MACHINES; i++ {
+		resource := godes.NewResource(fmt.Sprintf("Machine #%v", i), 1).SetPolicy(godes.WaitWork)
+		failure := godes.NewFailure(fmt.Sprintf("Breakdown #%v", i), resource,
+			func() float64 { return breaksGen.Get(1 / MTTF) },
+			func() float64 { return repairGen.Get(REPAIR_TIME, REPAIR_TIME_SIGMA) }).
+			SetRepairCrew(repairman).
+			SetUsageBased(true)
+		machine := &Machine{&godes.Runner{}, resource, 0}
+		machines = append(machines, machine)
+		failures = append(failures, failure)
+		godes.AddRunner(machine)
+	}
+	godes.WaitUntilDone()
+	for _, machine := range machines {
+		fmt.Printf("%v parts=%v\n", machine.resource.GetId(), machine.partsCount)
+	}
+	godes.PrintFailures(failures...)
+}
+
+/* OUTPUT
+Machine #0 parts=3435
+Machine #1 parts=3588
+Machine #2 parts=3538
+Machine #3 parts=3458
+Machine #4 parts=3396
+Machine #5 parts=3334
+Machine #6 parts=3305
+Machine #7 parts=3228
+Machine #8 parts=3053
+Machine #9 parts=2933
+Failure		Resource		Failures	Up Time	Down Time	Availability	MTBF	MTTR
+Breakdown #0	Machine #0	140	34450.979	5957.065	0.8526		246.078	42.550
+Breakdown #1	Machine #1	102	35821.074	4586.970	0.8865		351.187	44.970
+Breakdown #2	Machine #2	108	35349.063	5058.981	0.8748		327.306	46.842
+Breakdown #3	Machine #3	119	34711.853	5696.190	0.8590		291.696	47.867
+Breakdown #4	Machine #4	118	33940.659	6467.384	0.8399		287.633	54.808
+Breakdown #5	Machine #5	111	33501.753	6906.291	0.8291		301.818	62.219
+Breakdown #6	Machine #6	106	33240.657	7167.386	0.8226		313.591	67.617
+Breakdown #7	Machine #7	103	32620.676	7787.368	0.8073		316.706	75.606
+Breakdown #8	Machine #8	106	30706.817	9701.227	0.7599		289.687	91.521
+Breakdown #9	Machine #9	96	29643.522	10764.521	0.7336		308.787	112.130
+*/
@@ -0,0 +1,144 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+package main
+
+/*
+Procces Description:
+===================
+Customers arrive at a bank with three tellers from 8:00 until 16:00.
+The simulation is ended when the last customer has been served.
+
+Task
+====
+Save the checkpoint of the run at 12:00, restore it in the new simulation and
+check that the restored run is identical to the original one.
+Fork the run at 12:00 into the what-if branch where the service is 20% faster.
+
+Model Features:
+===============
+1. Checkpoint
+RunUntil pauses the simulation, GetCheckpoint returns its position and
+Save writes it into the file.
+
+2. Restore
+The model is built again by the same code, Restore replays it until the checkpoint
+and verifies the replay. The model uses the stream generators, so the replay
+reproduces the same random numbers.
+*/
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agoussia/godes"
+)
+
+// Input Parameters
+const (
+	ARRIVAL_INTERVAL = 0.5
+	SERVICE_TIME     = 1.3
+	SHUTDOWN_TIME    = 8 * 60.
+	CHECKPOINT_TIME  = 4 * 60.
+	REPLICATION      = 7
+)
+
+// the Bank keeps the state of one simulation
+type Bank struct {
+	sim       *godes.Simulation
+	arrival   *godes.ExpDistr
+	service   *godes.ExpDistr
+	tellers   *godes.Resource
+	speed     float64
+	served    int
+	elapsed   float64
+	departure float64
+}
+
+// the Customer is a Runner
+type Customer struct {
+	*godes.Runner
+	bank *Bank
+}
+
+func (customer *Customer) Run() {
+	bank := customer.bank
+	a0 := bank.sim.GetSystemTime()
+	bank.tellers.Acquire(customer)
+	bank.tellers.Work(customer, bank.service.Get(1./SERVICE_TIME)*bank.speed)
+	bank.tellers.Release(customer)
+	bank.served++
+	bank.departure = bank.sim.GetSystemTime()
+	bank.elapsed += bank.departure - a0
+}
+
+// the Source generates the customers until the shutdown time
+type Source struct {
+	*godes.Runner
+	bank *Bank
+}
+
+func (source *Source) Run() {
+	bank := source.bank
+	for bank.sim.GetSystemTime() < SHUTDOWN_TIME {
+		bank.sim.AddRunner(&Customer{&godes.Runner{}, bank})
+		bank.sim.Advance(bank.arrival.Get(1. / ARRIVAL_INTERVAL))
+	}
+}
+
+func newBank() *Bank {
+	sim := godes.NewSimulation()
+	bank := &Bank{
+		sim:     sim,
+		arrival: sim.NewExpDistrStream(1),
+		service: sim.NewExpDistrStream(2),
+		tellers: sim.NewResource("Tellers", 3),
+		speed:   1,
+	}
+	sim.SetReplication(REPLICATION, false)
+	sim.Run()
+	sim.AddRunner(&Source{&godes.Runner{}, bank})
+	return bank
+}
+
+func (bank *Bank) print(title string) {
+	fmt.Printf("%-10s served=%v elapsed=%v last=%v\n", title, bank.served, bank.elapsed/float64(bank.served), bank.departure)
+}
+
+func main() {
+	path := filepath.Join(os.TempDir(), "example12.json")
+
+	original := newBank()
+	original.sim.RunUntil(CHECKPOINT_TIME)
+	if err := original.sim.GetCheckpoint().Save(path); err != nil {
+		panic(err)
+	}
+	original.sim.WaitUntilDone()
+	original.print("Original")
+
+	cp, err := godes.LoadCheckpoint(path)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Checkpoint time=%v activations=%v runners=%v\n", cp.Time, cp.Activations, len(cp.Runners))
+
+	restored := newBank()
+	restored.sim.Restore(cp)
+	restored.sim.WaitUntilDone()
+	restored.print("Restored")
+
+	whatIf := newBank()
+	whatIf.sim.Restore(cp)
+	whatIf.speed = 0.8
+	whatIf.sim.WaitUntilDone()
+	whatIf.print("What-if")
+	os.Remove(path)
+}
+
+/* OUTPUT
+Original   served=937 elapsed=3.787265616228087 last=485.06417950803177
+Checkpoint time=240 activations=1711 runners=16
+Restored   served=937 elapsed=3.787265616228087 last=485.06417950803177
+What-if    served=937 elapsed=2.8761818580869405 last=482.539812534275
+*/
@@ -0,0 +1,76 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+package main
+
+/*
+Procces Description:
+===================
+A repair shop accepts the jobs during the first hour of the day and closes the office at 10:00.
+Two mechanics work the morning shift until 12:00, one mechanic works the afternoon shift.
+The jobs stop arriving long before the shift change, so the last job is finished in the morning.
+The simulation is ended when the office is closed and the last job has been finished.
+
+Task
+====
+Check that the run ends when the office is closed and not at the next shift boundary.
+
+Model Features:
+===============
+1. Schedule Daemon
+The capacity of the scheduled resource is changed by the schedule runner (the time unit is minute, zero is 8:00), which is a daemon:
+it does not keep the model alive when no other runner is left and terminates with the model.
+*/
+
+import (
+	"fmt"
+
+	"github.com/agoussia/godes"
+)
+
+// Input Parameters
+const (
+	ARRIVAL_INTERVAL = 5.
+	SERVICE_TIME     = 8.
+	OPEN_TIME        = 60.
+	CLOSE_TIME       = 2 * 60.
+)
+
+var shifts = godes.NewSchedule(0).AddShift(0, 4*60, 2).AddShift(4*60, 8*60, 1)
+
+var arrival = godes.NewExpDistrStream(1)
+var service = godes.NewExpDistrStream(2)
+var mechanics = godes.NewScheduledResource("mechanics", shifts, godes.WaitWork)
+var jobs int
+var lastDeparture float64
+
+// the Job is a Runner
+type Job struct {
+	*godes.Runner
+}
+
+func (job *Job) Run() {
+	mechanics.Acquire(job)
+	mechanics.Work(job, service.Get(1./SERVICE_TIME))
+	mechanics.Release(job)
+	jobs++
+	lastDeparture = godes.GetSystemTime()
+}
+
+func main() {
+	godes.Run()
+	for godes.GetSystemTime() < OPEN_TIME {
+		godes.AddRunner(&Job{&godes.Runner{}})
+		godes.Advance(arrival.Get(1. / ARRIVAL_INTERVAL))
+	}
+	// the office is closed after the last job has been finished
+	godes.Advance(CLOSE_TIME - godes.GetSystemTime())
+	godes.WaitUntilDone()
+	fmt.Printf("Jobs=%v Last Departure=%6.3f End Time=%6.3f\n", jobs, lastDeparture, godes.GetSystemTime())
+	fmt.Printf("Finished \n")
+}
+
+/* OUTPUT
+Jobs=10 Last Departure=62.123 End Time=120.000
+Finished 
+*/
@@ -111,9 +111,9 @@ func main() {
 }
 
 /* OUTPUT
-Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum
-Elapsed Time	944	 2.591	 1.959	 2.466	 2.716	 0.005	11.189
-Queue Length	944	 2.411	 3.069	 2.215	 2.607	 0.000	13.000
-Queueing Time	944	 1.293	 1.533	 1.195	 1.391	 0.000	 6.994
-Service Time	944	 1.298	 1.247	 1.219	 1.378	 0.003	 7.824
+Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum	Half-W	Rel.Prec
+Elapsed Time	944	 2.591	 1.959	 2.466	 2.716	 0.005	11.189	 0.125	 0.048
+Queue Length	944	 2.411	 3.069	 2.215	 2.607	 0.000	13.000	 0.196	 0.081
+Queueing Time	944	 1.293	 1.533	 1.195	 1.391	 0.000	 6.994	 0.098	 0.076
+Service Time	944	 1.298	 1.247	 1.219	 1.378	 0.003	 7.824	 0.080	 0.061
 */
@@ -40,6 +40,8 @@ While finishing a customer run  the application creates data arrays for each mea
 	U-Bound-upper bound of the confidence interval  with 95% probability
 	Minimum- minimum value
 	Maximum- maximum value
+	Half-W- half-width of the confidence interval
+	Rel.Prec- half-width divided by the average
 */
 
 import (
@@ -165,10 +167,10 @@ func main() {
 }
 
 /* OUTPUT
-Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum
-Elapsed Time	100	 3.672	 1.217	 3.433	 3.910	 1.980	 8.722
-Queue Length	100	 4.684	 2.484	 4.197	 5.171	 1.539	14.615
-Queueing Time	100	 2.368	 1.194	 2.134	 2.602	 0.810	 7.350
-Service Time	100	 1.304	 0.044	 1.295	 1.312	 1.170	 1.432
+Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum	Half-W	Rel.Prec
+Elapsed Time	100	 3.671	 1.217	 3.429	 3.912	 1.980	 8.720	 0.241	 0.066
+Queue Length	100	 4.682	 2.483	 4.190	 5.175	 1.539	14.609	 0.493	 0.105
+Queueing Time	100	 2.367	 1.194	 2.131	 2.604	 0.810	 7.348	 0.237	 0.100
+Service Time	100	 1.304	 0.044	 1.295	 1.312	 1.170	 1.432	 0.009	 0.007
 Finished
 */
@@ -0,0 +1,187 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+package main
+
+/*
+Procces Description:
+===================
+The bank from the Example 7 considers to employ four tellers instead of three.
+The doors of the bank close after eight hours.
+The simulation is ended when the last customer has been served.
+
+Task
+====
+Compare the configurations with three and four tellers.
+Calculate the confidence intervals for the differences of the performance measures.
+
+Model Features:
+===============
+1. Common Random Numbers
+The arrival and service generators are stream generators. The replication runner
+reseeds the streams before every replication, so the replication i of both
+configurations gets identical arrivals and service times.
+
+2. Paired Differences
+The differences between the replications of the configurations
+are collected into StatCollector with the narrow confidence intervals.
+The paired-t comparison shows if the difference is significant.
+*/
+
+import (
+	"fmt"
+
+	"github.com/agoussia/godes"
+)
+
+// Input Parameters
+const (
+	ARRIVAL_INTERVAL = 0.5
+	SERVICE_TIME     = 1.3
+	SHUTDOWN_TIME    = 8 * 60.
+	INDEPENDENT_RUNS = 30
+)
+
+// the arrival and service are two stream generators for the exponential  distribution
+var arrival *godes.ExpDistr = godes.NewExpDistrStream(1)
+var service *godes.ExpDistr = godes.NewExpDistrStream(2)
+
+// true when any counter is available
+var counterSwt *godes.BooleanControl = godes.NewBooleanControl()
+
+// FIFO Queue for the arrived customers
+var customerArrivalQueue *godes.FIFOQueue = godes.NewFIFOQueue("0")
+
+var tellers *Tellers
+var replicationStats [][]float64
+var titles = []string{
+	"Elapsed Time",
+	"Queue Length",
+	"Queueing Time",
+	"Service Time",
+}
+
+var availableTellers int = 0
+
+// the Tellers is a Passive Object represebting resource
+type Tellers struct {
+	max int
+}
+
+func (tellers *Tellers) Catch(customer *Customer) {
+	for {
+		counterSwt.Wait(true)
+		if customerArrivalQueue.GetHead().(*Customer).GetId() == customer.GetId() {
+			break
+		} else {
+			godes.Yield()
+		}
+	}
+	availableTellers++
+	if availableTellers == tellers.max {
+		counterSwt.Set(false)
+	}
+}
+
+func (tellers *Tellers) Release() {
+	availableTellers--
+	counterSwt.Set(true)
+}
+
+// the Customer is a Runner
+type Customer struct {
+	*godes.Runner
+	id int
+}
+
+func (customer *Customer) Run() {
+	a0 := godes.GetSystemTime()
+	tellers.Catch(customer)
+	a1 := godes.GetSystemTime()
+	customerArrivalQueue.Get()
+	qlength := float64(customerArrivalQueue.Len())
+	godes.Advance(service.Get(1. / SERVICE_TIME))
+	a2 := godes.GetSystemTime()
+	tellers.Release()
+	collectionArray := []float64{a2 - a0, qlength, a1 - a0, a2 - a1}
+	replicationStats = append(replicationStats, collectionArray)
+}
+
+func (customer *Customer) GetId() int {
+	return customer.id
+}
+
+// bank returns the replication of the bank with the number of tellers
+func bank(max int) godes.Replication {
+	return func(run int) []float64 {
+		tellers = &Tellers{max}
+		availableTellers = 0
+		replicationStats = [][]float64{}
+		godes.Run()
+		counterSwt.Set(true)
+		customerArrivalQueue.Clear()
+		count := 0
+		for {
+			customer := &Customer{&godes.Runner{}, count}
+			customerArrivalQueue.Place(customer)
+			godes.AddRunner(customer)
+			godes.Advance(arrival.Get(1. / ARRIVAL_INTERVAL))
+			if godes.GetSystemTime() > SHUTDOWN_TIME {
+				break
+			}
+			count++
+		}
+		godes.WaitUntilDone() // waits for all the runners to finish the Run()
+		godes.Clear()
+		replicationCollector := godes.NewStatCollector(titles, replicationStats)
+		return []float64{
+			replicationCollector.GetAverage(0),
+			replicationCollector.GetAverage(1),
+			replicationCollector.GetAverage(2),
+			replicationCollector.GetAverage(3),
+		}
+	}
+}
+
+func main() {
+	three := godes.Replicate(INDEPENDENT_RUNS, titles, bank(3))
+	four := godes.Replicate(INDEPENDENT_RUNS, titles, bank(4))
+	fmt.Println("Three Tellers")
+	three.PrintStat()
+	fmt.Println("Four Tellers")
+	four.PrintStat()
+	fmt.Println("Difference")
+	godes.PairedDifferences(three, four).PrintStat()
+	fmt.Println("Comparison")
+	scenarios := []*godes.StatCollector{three, four}
+	godes.PrintComparisons(godes.Compare([]string{"Three", "Four"}, scenarios, godes.PairedT, godes.Bonferroni, 0.95))
+	fmt.Printf("Finished \n")
+}
+
+/* OUTPUT
+Three Tellers
+Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum	Half-W	Rel.Prec
+Elapsed Time	30	 3.685	 1.513	 3.121	 4.250	 1.992	10.579	 0.565	 0.153
+Queue Length	30	 4.762	 3.131	 3.592	 5.931	 1.505	19.055	 1.169	 0.246
+Queueing Time	30	 2.397	 1.493	 1.840	 2.955	 0.788	 9.228	 0.558	 0.233
+Service Time	30	 1.288	 0.045	 1.271	 1.305	 1.204	 1.382	 0.017	 0.013
+Four Tellers
+Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum	Half-W	Rel.Prec
+Elapsed Time	30	 1.604	 0.120	 1.559	 1.649	 1.359	 1.813	 0.045	 0.028
+Queue Length	30	 0.627	 0.220	 0.545	 0.709	 0.261	 1.108	 0.082	 0.131
+Queueing Time	30	 0.316	 0.095	 0.281	 0.351	 0.155	 0.497	 0.035	 0.112
+Service Time	30	 1.288	 0.045	 1.271	 1.305	 1.204	 1.382	 0.017	 0.013
+Difference
+Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum	Half-W	Rel.Prec
+Elapsed Time	30	 2.081	 1.437	 1.545	 2.618	 0.633	 8.766	 0.536	 0.258
+Queue Length	30	 4.134	 2.997	 3.015	 5.253	 1.218	18.069	 1.119	 0.271
+Queueing Time	30	 2.081	 1.437	 1.545	 2.618	 0.633	 8.766	 0.536	 0.258
+Service Time	30	 0.000	 0.000	-0.000	 0.000	-0.000	 0.000	 0.000	 3.780
+Comparison
+Variable		Scenarios		Diff.	L-Bound	U-Bound	Level	Significant
+Elapsed Time	Three - Four	 2.081	 1.383	 2.780	0.9875	yes
+Queue Length	Three - Four	 4.134	 2.677	 5.591	0.9875	yes
+Queueing Time	Three - Four	 2.081	 1.383	 2.780	0.9875	yes
+Service Time	Three - Four	 0.000	-0.000	 0.000	0.9875	no
+Finished 
+*/
@@ -0,0 +1,151 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+package main
+
+/*
+Procces Description:
+===================
+See example 7.
+
+Task
+====
+Execute the replications of the example 7 concurrently on all the CPU cores.
+
+Model Features:
+===============
+1. Simulation Instances
+Each replication is executed on its own godes.Simulation instance.
+The runners call the methods of the instance (Advance, Yield) instead of the
+package-level functions, and the queue, control and generators are created by the instance.
+
+2. Parallel Replications
+ReplicateParallel distributes the replications between the workers.
+The stream generators of every instance are seeded for the replication, so the
+results don't depend on the number of workers.
+*/
+
+import (
+	"fmt"
+
+	"github.com/agoussia/godes"
+)
+
+// Input Parameters
+const (
+	ARRIVAL_INTERVAL = 0.5
+	SERVICE_TIME     = 1.3
+	SHUTDOWN_TIME    = 8 * 60.
+	INDEPENDENT_RUNS = 100
+)
+
+var titles = []string{
+	"Elapsed Time",
+	"Queue Length",
+	"Queueing Time",
+	"Service Time",
+}
+
+// the Bank keeps the state of one replication
+type Bank struct {
+	sim                  *godes.Simulation
+	arrival              *godes.ExpDistr
+	service              *godes.ExpDistr
+	counterSwt           *godes.BooleanControl
+	customerArrivalQueue *godes.FIFOQueue
+	availableTellers     int
+	maxTellers           int
+	replicationStats     [][]float64
+}
+
+func (bank *Bank) Catch(customer *Customer) {
+	for {
+		bank.counterSwt.Wait(true)
+		if bank.customerArrivalQueue.GetHead().(*Customer).GetId() == customer.GetId() {
+			break
+		} else {
+			bank.sim.Yield()
+		}
+	}
+	bank.availableTellers++
+	if bank.availableTellers == bank.maxTellers {
+		bank.counterSwt.Set(false)
+	}
+}
+
+func (bank *Bank) Release() {
+	bank.availableTellers--
+	bank.counterSwt.Set(true)
+}
+
+// the Customer is a Runner
+type Customer struct {
+	*godes.Runner
+	bank *Bank
+	id   int
+}
+
+func (customer *Customer) Run() {
+	bank := customer.bank
+	a0 := bank.sim.GetSystemTime()
+	bank.Catch(customer)
+	a1 := bank.sim.GetSystemTime()
+	bank.customerArrivalQueue.Get()
+	qlength := float64(bank.customerArrivalQueue.Len())
+	bank.sim.Advance(bank.service.Get(1. / SERVICE_TIME))
+	a2 := bank.sim.GetSystemTime()
+	bank.Release()
+	collectionArray := []float64{a2 - a0, qlength, a1 - a0, a2 - a1}
+	bank.replicationStats = append(bank.replicationStats, collectionArray)
+}
+
+func (customer *Customer) GetId() int {
+	return customer.id
+}
+
+func replication(sim *godes.Simulation, run int) []float64 {
+	bank := &Bank{
+		sim:                  sim,
+		arrival:              sim.NewExpDistrStream(1),
+		service:              sim.NewExpDistrStream(2),
+		counterSwt:           sim.NewBooleanControl(),
+		customerArrivalQueue: sim.NewFIFOQueue("0"),
+		maxTellers:           3,
+	}
+	sim.Run()
+	bank.counterSwt.Set(true)
+	count := 0
+	for {
+		customer := &Customer{&godes.Runner{}, bank, count}
+		bank.customerArrivalQueue.Place(customer)
+		sim.AddRunner(customer)
+		sim.Advance(bank.arrival.Get(1. / ARRIVAL_INTERVAL))
+		if sim.GetSystemTime() > SHUTDOWN_TIME {
+			break
+		}
+		count++
+	}
+	sim.WaitUntilDone() // waits for all the runners to finish the Run()
+	replicationCollector := godes.NewStatCollector(titles, bank.replicationStats)
+	return []float64{
+		replicationCollector.GetAverage(0),
+		replicationCollector.GetAverage(1),
+		replicationCollector.GetAverage(2),
+		replicationCollector.GetAverage(3),
+	}
+}
+
+func main() {
+	collector := godes.ReplicateParallel(INDEPENDENT_RUNS, titles, 0, replication)
+	collector.PrintStat()
+	fmt.Printf("Finished \n")
+}
+
+/* OUTPUT
+Variable		#	Average	Std Dev	L-Bound	U-Bound	Minimum	Maximum	Half-W	Rel.Prec
+Elapsed Time	100	 3.578	 1.134	 3.353	 3.803	 1.936	10.579	 0.225	 0.063
+Queue Length	100	 4.544	 2.368	 4.074	 5.014	 1.217	19.055	 0.470	 0.103
+Queueing Time	100	 2.284	 1.116	 2.063	 2.506	 0.678	 9.228	 0.221	 0.097
+Service Time	100	 1.294	 0.041	 1.286	 1.302	 1.201	 1.382	 0.008	 0.006
+Finished 
+*/
@@ -0,0 +1,303 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Experiment executes the replications of the model for every point of the design.
+// The design is built from the factors as the full factorial, Latin hypercube
+// or custom list of points. The results are written as the tidy table with one row
+// per design point, replication and measure to CSV or JSON Lines file.
+// The replication i of every design point uses the same stream seeds (common random numbers).
+
+package godes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// Factor is the input parameter of the experiment.
+// Levels are used by the full factorial design,
+// Min and Max are used by the Latin hypercube design
+type Factor struct {
+	Name   string
+	Levels []float64
+	Min    float64
+	Max    float64
+}
+
+// DesignPoint is the combination of the factor values
+type DesignPoint map[string]float64
+
+// Design is the list of the design points
+type Design struct {
+	Factors []string
+	Points  []DesignPoint
+}
+
+// FullFactorial returns the design with all the combinations of the factor levels
+func FullFactorial(factors []Factor) *Design {
+	if len(factors) == 0 {
+		panic("no factors")
+	}
+	design := &Design{Factors: factorNames(factors)}
+	design.Points = []DesignPoint{{}}
+	for _, f := range factors {
+		if len(f.Levels) == 0 {
+			panic("no levels for factor " + f.Name)
+		}
+		points := []DesignPoint{}
+		for _, p := range design.Points {
+			for _, level := range f.Levels {
+				q := DesignPoint{}
+				for k, v := range p {
+					q[k] = v
+				}
+				q[f.Name] = level
+				points = append(points, q)
+			}
+		}
+		design.Points = points
+	}
+	return design
+}
+
+// LatinHypercube returns the design with number of points sampled from the ranges [Min, Max]
+// of the factors. The seed makes the design reproducible
+func LatinHypercube(factors []Factor, points int, seed int64) *Design {
+	if len(factors) == 0 {
+		panic("no factors")
+	}
+	if points < 1 {
+		panic("invalid number of points")
+	}
+	generator := rand.New(rand.NewSource(seed))
+	design := &Design{Factors: factorNames(factors)}
+	design.Points = make([]DesignPoint, points)
+	for i := range design.Points {
+		design.Points[i] = DesignPoint{}
+	}
+	for _, f := range factors {
+		if f.Max < f.Min {
+			panic("invalid range for factor " + f.Name)
+		}
+		perm := generator.Perm(points)
+		for i, stratum := range perm {
+			u := (float64(stratum) + generator.Float64()) / float64(points)
+			design.Points[i][f.Name] = f.Min + u*(f.Max-f.Min)
+		}
+	}
+	return design
+}
+
+// CustomDesign returns the design with the points listed as values of the factors
+func CustomDesign(factors []string, points [][]float64) *Design {
+	if len(factors) == 0 {
+		panic("no factors")
+	}
+	design := &Design{Factors: append([]string{}, factors...)}
+	for _, values := range points {
+		if len(values) != len(factors) {
+			panic("invalid factors/points arrays")
+		}
+		p := DesignPoint{}
+		for i, name := range factors {
+			p[name] = values[i]
+		}
+		design.Points = append(design.Points, p)
+	}
+	return design
+}
+
+func factorNames(factors []Factor) []string {
+	names := []string{}
+	for _, f := range factors {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// DesignReplication executes one replication of the model for the design point
+type DesignReplication func(point DesignPoint, run int) []float64
+
+// ParallelDesignReplication executes one replication of the model for the design point
+// on its own simulation instance
+type ParallelDesignReplication func(sim *Simulation, point DesignPoint, run int) []float64
+
+// Experiment contains the results of the experiment
+type Experiment struct {
+	design   *Design
+	measures []string
+	// samples[point][run][measure]
+	samples [][][]float64
+}
+
+// RunExperiment executes runs replications for every point of the design
+func RunExperiment(design *Design, runs int, measures []string, replication DesignReplication) *Experiment {
+	if replication == nil {
+		panic("replication is nil")
+	}
+	experiment := newExperiment(design, runs, measures)
+	for i, point := range design.Points {
+		for run := 0; run < runs; run++ {
+			SetReplication(run, false)
+			experiment.set(i, run, replication(point, run))
+		}
+	}
+	SetReplication(0, false)
+	return experiment
+}
+
+// RunExperimentParallel executes runs replications for every point of the design concurrently
+// with the number of workers (the number of CPU cores if workers is zero).
+// Each replication is executed on the new simulation instance; as in ReplicateParallel
+// the repeatable generators shall be created with the methods of the sim
+func RunExperimentParallel(design *Design, runs int, measures []string, workers int, replication ParallelDesignReplication) *Experiment {
+	if replication == nil {
+		panic("replication is nil")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	experiment := newExperiment(design, runs, measures)
+	type job struct {
+		point int
+		run   int
+	}
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				sim := newRunSimulation(j.run)
+				experiment.set(j.point, j.run, replication(sim, design.Points[j.point], j.run))
+			}
+		}()
+	}
+	for i := range design.Points {
+		for run := 0; run < runs; run++ {
+			jobs <- job{i, run}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return experiment
+}
+
+func newExperiment(design *Design, runs int, measures []string) *Experiment {
+	if design == nil || len(design.Points) == 0 {
+		panic("empty design")
+	}
+	if runs < 1 {
+		panic("invalid number of runs")
+	}
+	if len(measures) == 0 {
+		panic("no measures")
+	}
+	for _, f := range design.Factors {
+		switch f {
+		case "point", "replication", "measure", "value":
+			panic("factor name is reserved for the column of the table")
+		}
+	}
+	experiment := &Experiment{design: design, measures: measures, samples: make([][][]float64, len(design.Points))}
+	for i := range experiment.samples {
+		experiment.samples[i] = make([][]float64, runs)
+	}
+	return experiment
+}
+
+func (experiment *Experiment) set(point int, run int, values []float64) {
+	if len(values) != len(experiment.measures) {
+		panic("invalid number of measures returned by replication")
+	}
+	experiment.samples[point][run] = values
+}
+
+// GetDesign returns the design of the experiment
+func (experiment *Experiment) GetDesign() *Design {
+	return experiment.design
+}
+
+// GetCollector returns StatCollector with the replications of the design point
+func (experiment *Experiment) GetCollector(point int) *StatCollector {
+	if point < 0 || point > len(experiment.samples)-1 {
+		panic("invalid index")
+	}
+	return NewStatCollector(experiment.measures, experiment.samples[point])
+}
+
+// header returns the columns of the tidy table
+func (experiment *Experiment) header() []string {
+	header := []string{"point"}
+	header = append(header, experiment.design.Factors...)
+	return append(header, "replication", "measure", "value")
+}
+
+// WriteCSV writes the tidy table with one row per design point, replication and measure
+func (experiment *Experiment) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(experiment.header()); err != nil {
+		return err
+	}
+	for i, point := range experiment.design.Points {
+		for run, values := range experiment.samples[i] {
+			for m, value := range values {
+				row := []string{strconv.Itoa(i)}
+				for _, f := range experiment.design.Factors {
+					row = append(row, strconv.FormatFloat(point[f], 'g', -1, 64))
+				}
+				row = append(row, strconv.Itoa(run), experiment.measures[m], strconv.FormatFloat(value, 'g', -1, 64))
+				if err := writer.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSONL writes the tidy table as JSON Lines, one object per design point, replication and measure.
+// NaN and infinite values are written as null
+func (experiment *Experiment) WriteJSONL(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for i, point := range experiment.design.Points {
+		for run, values := range experiment.samples[i] {
+			for m, value := range values {
+				row := map[string]interface{}{
+					"point":       i,
+					"replication": run,
+					"measure":     experiment.measures[m],
+					"value":       jsonNumber(value),
+				}
+				for _, f := range experiment.design.Factors {
+					row[f] = jsonNumber(point[f])
+				}
+				if err := encoder.Encode(row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// jsonNumber returns nil for the values which can not be encoded as JSON number
+func jsonNumber(x float64) interface{} {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil
+	}
+	return x
+}
@@ -0,0 +1,299 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Failure is the failure and repair process of one unit of the Resource.
+// The time to failure is measured in the simulation time (calendar based) or
+// in the busy time of the resource (usage based). The failed unit is removed from
+// the capacity and the work in progress is handled according to the policy of the resource.
+// The repair takes the time to repair and, if the repair crew is set,
+// the unit of the crew resource for this time.
+// The failures of the resource are started with its first Acquire and
+// the availability, MTBF and MTTR are collected automatically.
+
+package godes
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+)
+
+// Failure represents the failure and repair process of one unit of the resource
+type Failure struct {
+	id         string
+	resource   *Resource
+	ttf        func() float64
+	ttr        func() float64
+	crew       *Resource
+	usageBased bool
+	started    bool
+	startTime  float64
+	failed     bool
+	failedAt   float64
+	failures   int
+	repairs    int
+	repairTime float64
+	// the usage-based failure waits for the target usage of the resource
+	runner      *failureRunner
+	waiting     bool
+	usageTarget float64
+	inUse       *BooleanControl
+}
+
+// NewFailure creates the failure of one unit of the resource with the time to failure
+// and the time to repair functions. For the resource with several units
+// the failures are created for every unit
+func NewFailure(id string, resource *Resource, ttf func() float64, ttr func() float64) *Failure {
+	if resource == nil {
+		panic("resource is nil")
+	}
+	if ttf == nil || ttr == nil {
+		panic("time function is nil")
+	}
+	f := &Failure{id: id, resource: resource, ttf: ttf, ttr: ttr}
+	resource.failures = append(resource.failures, f)
+	if resource.started {
+		f.start()
+	}
+	return f
+}
+
+// SetRepairCrew sets the resource which units are required for the repair
+func (f *Failure) SetRepairCrew(crew *Resource) *Failure {
+	if f.started {
+		panic("failure is already started")
+	}
+	if crew == f.resource {
+		panic("resource can not repair itself")
+	}
+	f.crew = crew
+	return f
+}
+
+// SetUsageBased sets the time to failure to be measured in the busy time of the resource
+func (f *Failure) SetUsageBased(usageBased bool) *Failure {
+	if f.started {
+		panic("failure is already started")
+	}
+	f.usageBased = usageBased
+	return f
+}
+
+// GetId returns the id of the failure
+func (f *Failure) GetId() string {
+	return f.id
+}
+
+// IsFailed returns true if the unit is failed
+func (f *Failure) IsFailed() bool {
+	return f.failed
+}
+
+// GetFailures returns the number of the failures
+func (f *Failure) GetFailures() int {
+	return f.failures
+}
+
+// GetDownTime returns the time the unit was failed
+func (f *Failure) GetDownTime() float64 {
+	down := f.repairTime
+	if f.failed {
+		down += f.now() - f.failedAt
+	}
+	return down
+}
+
+// GetUpTime returns the time the unit was working since the failure was started
+func (f *Failure) GetUpTime() float64 {
+	if !f.started {
+		return 0
+	}
+	return f.now() - f.startTime - f.GetDownTime()
+}
+
+// GetAvailability returns the fraction of the time the unit was working
+func (f *Failure) GetAvailability() float64 {
+	total := f.GetUpTime() + f.GetDownTime()
+	if total == 0 {
+		return 1
+	}
+	return f.GetUpTime() / total
+}
+
+// GetMTBF returns the mean working time between the failures
+func (f *Failure) GetMTBF() float64 {
+	if f.failures == 0 {
+		return 0
+	}
+	return f.GetUpTime() / float64(f.failures)
+}
+
+// GetMTTR returns the mean down time of the completed repairs, including the wait for the repair crew
+func (f *Failure) GetMTTR() float64 {
+	if f.repairs == 0 {
+		return 0
+	}
+	return f.repairTime / float64(f.repairs)
+}
+
+// PrintFailures prints the table of the failure statistics
+func PrintFailures(failures ...*Failure) {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 10, 1, '\t', 0)
+	fmt.Fprintln(w, "Failure\tResource\tFailures\tUp Time\tDown Time\tAvailability\tMTBF\tMTTR")
+	for _, f := range failures {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%6.3f\t%6.3f\t%6.4f\t%6.3f\t%6.3f\n", f.id, f.resource.id, f.failures, f.GetUpTime(), f.GetDownTime(), f.GetAvailability(), f.GetMTBF(), f.GetMTTR())
+	}
+	w.Flush()
+}
+
+func (f *Failure) now() float64 {
+	return getSimulation(f.resource.sim).GetSystemTime()
+}
+
+// start adds the runner of the failure
+func (f *Failure) start() {
+	f.started = true
+	f.startTime = f.now()
+	f.runner = &failureRunner{Runner: &Runner{}, failure: f}
+	f.inUse = getSimulation(f.resource.sim).NewBooleanControl()
+	getSimulation(f.resource.sim).AddRunner(f.runner)
+}
+
+// reschedule changes the time of the usage-based failure when the utilization of the resource changes
+func (f *Failure) reschedule() {
+	if !f.waiting {
+		return
+	}
+	sim := getSimulation(f.resource.sim)
+	rate := f.resource.utilization()
+	switch f.runner.getState() {
+	case RunnerScheduled:
+		// the runner is held while the resource is idle
+		sim.Interrupt(f.runner)
+		if rate > 0 {
+			f.resume(rate)
+		}
+	case RunnerInterrupted:
+		if rate > 0 {
+			f.resume(rate)
+		}
+	case RunnerWaiting:
+		if rate > 0 {
+			f.inUse.Set(true)
+		}
+	}
+}
+
+// resume schedules the interrupted runner at the time the target usage is reached
+func (f *Failure) resume(rate float64) {
+	next := f.now() + math.Max(0, f.usageTarget-f.resource.getUsage())/rate
+	getSimulation(f.resource.sim).Resume(f.runner, next-f.runner.getMovingTime())
+}
+
+// fail removes the unit from the capacity of the resource
+func (f *Failure) fail() {
+	f.failed = true
+	f.failedAt = f.now()
+	f.failures++
+	f.resource.down++
+	f.resource.applyCapacity()
+}
+
+// repair returns the unit into the capacity of the resource
+func (f *Failure) repair() {
+	f.failed = false
+	f.repairs++
+	f.repairTime += f.now() - f.failedAt
+	f.resource.down--
+	f.resource.applyCapacity()
+}
+
+// failureRunner fails and repairs the unit of the resource.
+// It terminates when no other runner is left in the model
+type failureRunner struct {
+	*Runner
+	failure *Failure
+}
+
+func (s *failureRunner) daemon() {}
+
+func (s *failureRunner) Run() {
+	f := s.failure
+	for {
+		if !s.waitFailure() {
+			return
+		}
+		f.fail()
+		if !s.waitRepair() {
+			return
+		}
+		f.repair()
+	}
+}
+
+// waitFailure advances the runner until the time to failure is elapsed.
+// It returns false if no other runner is left
+func (s *failureRunner) waitFailure() bool {
+	f := s.failure
+	sim := getSimulation(f.resource.sim)
+	ttf := f.ttf()
+	if !f.usageBased {
+		sim.Advance(ttf)
+		return sim.mdl.hasOtherRunners()
+	}
+	// the runner is scheduled at the time the target usage is reached with the current utilization
+	// and is rescheduled by the resource when the utilization changes
+	f.usageTarget = f.resource.getUsage() + ttf
+	for {
+		remaining := f.usageTarget - f.resource.getUsage()
+		if remaining <= 1e-9*(1+f.usageTarget) {
+			return true
+		}
+		f.waiting = true
+		if rate := f.resource.utilization(); rate > 0 {
+			sim.Advance(remaining / rate)
+		} else {
+			f.inUse.Set(false)
+			f.inUse.Wait(true)
+		}
+		f.waiting = false
+		if !sim.mdl.hasOtherRunners() {
+			return false
+		}
+	}
+}
+
+// waitRepair acquires the repair crew and advances the runner for the time to repair.
+// It returns false if no other runner is left
+func (s *failureRunner) waitRepair() bool {
+	f := s.failure
+	sim := getSimulation(f.resource.sim)
+	remaining := f.ttr()
+	if f.crew == nil {
+		sim.Advance(remaining)
+		return sim.mdl.hasOtherRunners()
+	}
+	for {
+		if !f.crew.acquire(s) {
+			return false
+		}
+		started := sim.GetSystemTime()
+		completed := f.crew.Work(s, remaining)
+		if !sim.mdl.hasOtherRunners() {
+			return false
+		}
+		f.crew.Release(s)
+		if completed {
+			return true
+		}
+		// the crew was preempted, the rest of the repair waits for the next unit
+		remaining -= sim.GetSystemTime() - started
+	}
+}
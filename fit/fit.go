@@ -0,0 +1,293 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Package fit estimates the parameters of the distributions supported
+// by the godes random generators from the sample data.
+// The fitted distributions are ranked by the Kolmogorov-Smirnov,
+// Anderson-Darling and chi-square goodness-of-fit statistics.
+// The best fitted distribution provides the ready-to-use generator.
+//
+// The parameters are estimated as follows:
+//
+//	Uniform: Min, Max - maximum likelihood (sample minimum and maximum)
+//	Normal: Mean and Standard Deviation - maximum likelihood
+//	Exponential: Lambda - maximum likelihood (1/mean)
+//	Triangular: Min, Max, Mode - sample minimum and maximum, mode by moments
+package fit
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/agoussia/godes"
+)
+
+// Names of the supported distributions
+const (
+	Uniform     = "Uniform"
+	Normal      = "Normal"
+	Exponential = "Exponential"
+	Triangular  = "Triangular"
+)
+
+// Criterion is the goodness-of-fit statistic used for ranking
+type Criterion int
+
+// Goodness-of-fit criteria
+const (
+	KolmogorovSmirnov Criterion = iota
+	AndersonDarling
+	ChiSquare
+)
+
+// Result is the distribution fitted to the sample
+type Result struct {
+	// Distribution is the name of the distribution
+	Distribution string
+	// Params are the estimated parameters in the order
+	// of the Get method of the godes generator
+	Params []float64
+	// KS is the Kolmogorov-Smirnov statistic and KSPValue is its asymptotic p-value
+	KS       float64
+	KSPValue float64
+	// AD is the Anderson-Darling statistic
+	AD float64
+	// ChiSquare is the chi-square statistic with ChiSquareDF degrees of freedom
+	ChiSquare       float64
+	ChiSquareDF     int
+	ChiSquarePValue float64
+	cdf             func(x float64) float64
+}
+
+// Fit fits all the applicable distributions to the data
+// and returns the results ranked by the Kolmogorov-Smirnov statistic
+func Fit(data []float64) []*Result {
+	return FitAndRank(data, KolmogorovSmirnov)
+}
+
+// FitAndRank fits all the applicable distributions to the data
+// and returns the results ranked by the criterion
+func FitAndRank(data []float64, criterion Criterion) []*Result {
+	results := []*Result{FitUniform(data), FitNormal(data), FitTriangular(data)}
+	if min, _ := godes.MinMax(data); min >= 0 {
+		results = append(results, FitExponential(data))
+	}
+	Rank(results, criterion)
+	return results
+}
+
+// Best returns the best fitted distribution according to the criterion
+func Best(data []float64, criterion Criterion) *Result {
+	return FitAndRank(data, criterion)[0]
+}
+
+// Rank sorts the results in the ascending order of the criterion statistic
+func Rank(results []*Result, criterion Criterion) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Statistic(criterion) < results[j].Statistic(criterion)
+	})
+}
+
+// Statistic returns the value of the criterion statistic
+func (r *Result) Statistic(criterion Criterion) float64 {
+	switch criterion {
+	case KolmogorovSmirnov:
+		return r.KS
+	case AndersonDarling:
+		return r.AD
+	case ChiSquare:
+		return r.ChiSquare
+	default:
+		panic("unknown criterion")
+	}
+}
+
+// FitUniform fits the uniform distribution
+func FitUniform(data []float64) *Result {
+	checkData(data)
+	min, max := godes.MinMax(data)
+	if min == max {
+		panic("degenerate sample")
+	}
+	cdf := func(x float64) float64 {
+		return math.Max(0, math.Min(1, (x-min)/(max-min)))
+	}
+	return newResult(Uniform, []float64{min, max}, 2, cdf, data)
+}
+
+// FitNormal fits the normal distribution
+func FitNormal(data []float64) *Result {
+	checkData(data)
+	mean := godes.Mean(data)
+	sigma := 0.
+	for _, x := range data {
+		sigma += (x - mean) * (x - mean)
+	}
+	sigma = math.Sqrt(sigma / float64(len(data)))
+	if sigma == 0 {
+		panic("degenerate sample")
+	}
+	cdf := func(x float64) float64 {
+		return 0.5 * math.Erfc(-(x-mean)/(sigma*math.Sqrt2))
+	}
+	return newResult(Normal, []float64{mean, sigma}, 2, cdf, data)
+}
+
+// FitExponential fits the exponential distribution. The data shall not be negative
+func FitExponential(data []float64) *Result {
+	checkData(data)
+	if min, _ := godes.MinMax(data); min < 0 {
+		panic("negative data for exponential distribution")
+	}
+	mean := godes.Mean(data)
+	if mean == 0 {
+		panic("degenerate sample")
+	}
+	lambda := 1. / mean
+	cdf := func(x float64) float64 {
+		if x <= 0 {
+			return 0
+		}
+		return 1 - math.Exp(-lambda*x)
+	}
+	return newResult(Exponential, []float64{lambda}, 1, cdf, data)
+}
+
+// FitTriangular fits the triangular distribution
+func FitTriangular(data []float64) *Result {
+	checkData(data)
+	min, max := godes.MinMax(data)
+	if min == max {
+		panic("degenerate sample")
+	}
+	mode := 3*godes.Mean(data) - min - max
+	mode = math.Max(min, math.Min(max, mode))
+	cdf := func(x float64) float64 {
+		switch {
+		case x <= min:
+			return 0
+		case x >= max:
+			return 1
+		case x <= mode:
+			return (x - min) * (x - min) / ((max - min) * (mode - min))
+		default:
+			return 1 - (max-x)*(max-x)/((max-min)*(max-mode))
+		}
+	}
+	return newResult(Triangular, []float64{min, max, mode}, 3, cdf, data)
+}
+
+// CDF returns the value of the fitted cumulative distribution function
+func (r *Result) CDF(x float64) float64 {
+	return r.cdf(x)
+}
+
+// Generator returns the godes generator for the fitted distribution.
+// If repetition flag is true, the generator will generate the same sequences for every execution
+func (r *Result) Generator(repetion bool) *Generator {
+	p := r.Params
+	switch r.Distribution {
+	case Uniform:
+		g := godes.NewUniformDistr(repetion)
+		return &Generator{r, func() float64 { return g.Get(p[0], p[1]) }}
+	case Normal:
+		g := godes.NewNormalDistr(repetion)
+		return &Generator{r, func() float64 { return g.Get(p[0], p[1]) }}
+	case Exponential:
+		g := godes.NewExpDistr(repetion)
+		return &Generator{r, func() float64 { return g.Get(p[0]) }}
+	case Triangular:
+		g := godes.NewTriangularDistr(repetion)
+		return &Generator{r, func() float64 { return g.Get(p[0], p[1], p[2]) }}
+	default:
+		panic("unknown distribution")
+	}
+}
+
+// StreamGenerator returns the godes stream generator for the fitted distribution
+func (r *Result) StreamGenerator(stream int) *Generator {
+	p := r.Params
+	switch r.Distribution {
+	case Uniform:
+		g := godes.NewUniformDistrStream(stream)
+		return &Generator{r, func() float64 { return g.Get(p[0], p[1]) }}
+	case Normal:
+		g := godes.NewNormalDistrStream(stream)
+		return &Generator{r, func() float64 { return g.Get(p[0], p[1]) }}
+	case Exponential:
+		g := godes.NewExpDistrStream(stream)
+		return &Generator{r, func() float64 { return g.Get(p[0]) }}
+	case Triangular:
+		g := godes.NewTriangularDistrStream(stream)
+		return &Generator{r, func() float64 { return g.Get(p[0], p[1], p[2]) }}
+	default:
+		panic("unknown distribution")
+	}
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%s%v KS=%6.4f AD=%6.4f ChiSq=%6.4f", r.Distribution, r.Params, r.KS, r.AD, r.ChiSquare)
+}
+
+// Generator is the random generator with the fitted parameters
+type Generator struct {
+	result *Result
+	get    func() float64
+}
+
+// Get returns new random value from the fitted distribution
+func (g *Generator) Get() float64 {
+	return g.get()
+}
+
+// GetResult returns the fitted distribution of the generator
+func (g *Generator) GetResult() *Result {
+	return g.result
+}
+
+// PrintResults prints the ranked results
+func PrintResults(results []*Result) {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 10, 0, '\t', 0)
+	fmt.Fprintln(w, "Distribution\tParameters\tK-S\tK-S p\tA-D\tChi-Sq\tDF\tChi-Sq p")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%6.4f\t%6.4f\t%6.4f\t%6.4f\t%d\t%6.4f\n", r.Distribution, formatParams(r.Params), r.KS, r.KSPValue, r.AD, r.ChiSquare, r.ChiSquareDF, r.ChiSquarePValue)
+	}
+	w.Flush()
+}
+
+func formatParams(params []float64) string {
+	s := ""
+	for i, p := range params {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%.4g", p)
+	}
+	return s
+}
+
+func checkData(data []float64) {
+	if len(data) < 2 {
+		panic("not enough data")
+	}
+}
+
+// newResult calculates the goodness-of-fit statistics
+func newResult(name string, params []float64, estimated int, cdf func(float64) float64, data []float64) *Result {
+	sorted := append([]float64{}, data...)
+	sort.Float64s(sorted)
+	r := &Result{Distribution: name, Params: params, cdf: cdf}
+	r.KS = kolmogorovSmirnov(sorted, cdf)
+	r.KSPValue = kolmogorovPValue(r.KS, len(sorted))
+	r.AD = andersonDarling(sorted, cdf)
+	r.ChiSquare, r.ChiSquareDF = chiSquare(sorted, cdf, estimated)
+	if r.ChiSquareDF > 0 {
+		r.ChiSquarePValue = 1 - regularizedGammaP(float64(r.ChiSquareDF)/2, r.ChiSquare/2)
+	}
+	return r
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Goodness-of-fit statistics
+
+package fit
+
+import (
+	"math"
+)
+
+// kolmogorovSmirnov returns the K-S statistic for the sorted sample
+func kolmogorovSmirnov(sorted []float64, cdf func(float64) float64) float64 {
+	n := float64(len(sorted))
+	d := 0.
+	for i, x := range sorted {
+		f := cdf(x)
+		d = math.Max(d, math.Max(float64(i+1)/n-f, f-float64(i)/n))
+	}
+	return d
+}
+
+// kolmogorovPValue returns the asymptotic p-value of the K-S statistic
+func kolmogorovPValue(d float64, n int) float64 {
+	sn := math.Sqrt(float64(n))
+	t := (sn + 0.12 + 0.11/sn) * d
+	if t < 0.2 {
+		return 1
+	}
+	sum := 0.
+	for j := 1; j <= 100; j++ {
+		term := 2 * math.Exp(-2*float64(j*j)*t*t)
+		if j%2 == 0 {
+			sum -= term
+		} else {
+			sum += term
+		}
+		if term < 1e-12 {
+			break
+		}
+	}
+	return math.Max(0, math.Min(1, sum))
+}
+
+// andersonDarling returns the A-D statistic for the sorted sample
+func andersonDarling(sorted []float64, cdf func(float64) float64) float64 {
+	const eps = 1e-12
+	n := len(sorted)
+	s := 0.
+	for i := 0; i < n; i++ {
+		lo := math.Max(eps, math.Min(1-eps, cdf(sorted[i])))
+		hi := math.Max(eps, math.Min(1-eps, cdf(sorted[n-1-i])))
+		s += float64(2*i+1) * (math.Log(lo) + math.Log(1-hi))
+	}
+	return -float64(n) - s/float64(n)
+}
+
+// chiSquare returns the chi-square statistic and its degrees of freedom.
+// The sample range is divided into equal width bins, the tails
+// of the distribution are included into the first and the last bins
+func chiSquare(sorted []float64, cdf func(float64) float64, estimated int) (float64, int) {
+	n := len(sorted)
+	k := int(math.Max(5, math.Ceil(math.Sqrt(float64(n)))))
+	min := sorted[0]
+	max := sorted[n-1]
+	width := (max - min) / float64(k)
+	observed := make([]float64, k)
+	for _, x := range sorted {
+		i := int((x - min) / width)
+		if i >= k {
+			i = k - 1
+		}
+		observed[i]++
+	}
+	stat := 0.
+	bins := 0
+	prev := 0.
+	for i := 0; i < k; i++ {
+		next := 1.
+		if i < k-1 {
+			next = cdf(min + float64(i+1)*width)
+		}
+		expected := float64(n) * (next - prev)
+		prev = next
+		if expected <= 0 {
+			continue
+		}
+		stat += (observed[i] - expected) * (observed[i] - expected) / expected
+		bins++
+	}
+	df := bins - 1 - estimated
+	if df < 0 {
+		df = 0
+	}
+	return stat, df
+}
+
+// regularizedGammaP returns the regularized lower incomplete gamma function P(a,x)
+func regularizedGammaP(a float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	lg, _ := math.Lgamma(a)
+	if x < a+1 {
+		// series representation
+		sum := 1. / a
+		term := sum
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return sum * math.Exp(-x+a*math.Log(x)-lg)
+	}
+	// continued fraction representation
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1. / tiny
+	d := 1. / b
+	h := d
+	for i := 1; i < 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1. / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < 1e-15 {
+			break
+		}
+	}
+	return 1 - math.Exp(-x+a*math.Log(x)-lg)*h
+}
@@ -0,0 +1,376 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Histogram collects the observations into the bins.
+// The fixed histogram has the predefined range with underflow and overflow counters.
+// The dynamic histogram starts from the range of the first observations and
+// doubles the bin width when the observation is out of the range.
+// The non-finite observations are counted as underflow (-Inf) or overflow (+Inf, NaN).
+//
+// P2Quantile is the streaming estimator of the quantile (P-square algorithm
+// of Jain and Chlamtac) which does not store the observations.
+
+package godes
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Histogram represents the fixed-bin or dynamic histogram
+type Histogram struct {
+	id        string
+	dynamic   bool
+	lo        float64
+	width     float64
+	counts    []int64
+	underflow int64
+	overflow  int64
+	count     int64
+	buffer    []float64
+}
+
+// NewHistogram creates the histogram with the fixed range [min, max) and number of bins
+func NewHistogram(id string, min float64, max float64, bins int) *Histogram {
+	if bins < 1 || max <= min {
+		panic("invalid histogram range")
+	}
+	return &Histogram{id: id, lo: min, width: (max - min) / float64(bins), counts: make([]int64, bins)}
+}
+
+// NewDynamicHistogram creates the histogram with the number of bins and the range
+// adjusted to the observations
+func NewDynamicHistogram(id string, bins int) *Histogram {
+	if bins < 2 || bins%2 != 0 {
+		panic("number of bins shall be even")
+	}
+	return &Histogram{id: id, dynamic: true, counts: make([]int64, bins)}
+}
+
+// Add adds the observation
+func (h *Histogram) Add(x float64) {
+	h.count++
+	if math.IsInf(x, -1) {
+		h.underflow++
+		return
+	}
+	if math.IsInf(x, 1) || math.IsNaN(x) {
+		h.overflow++
+		return
+	}
+	if !h.dynamic {
+		i := int(math.Floor((x - h.lo) / h.width))
+		switch {
+		case i < 0:
+			h.underflow++
+		case i >= len(h.counts):
+			h.overflow++
+		default:
+			h.counts[i]++
+		}
+		return
+	}
+	if h.buffer != nil || h.width == 0 {
+		h.buffer = append(h.buffer, x)
+		if len(h.buffer) == len(h.counts) {
+			h.flush()
+		}
+		return
+	}
+	for x < h.lo || x >= h.lo+h.width*float64(len(h.counts)) {
+		h.grow(x < h.lo)
+	}
+	h.counts[h.index(x)]++
+}
+
+func (h *Histogram) index(x float64) int {
+	i := int((x - h.lo) / h.width)
+	if i >= len(h.counts) {
+		i = len(h.counts) - 1
+	}
+	return i
+}
+
+// grow doubles the bin width of the dynamic histogram.
+// If down is true, the range is extended below the current minimum
+func (h *Histogram) grow(down bool) {
+	k := len(h.counts)
+	merged := make([]int64, k)
+	offset := 0
+	if down {
+		offset = k / 2
+		h.lo -= h.width * float64(k)
+	}
+	for i := 0; i < k/2; i++ {
+		merged[offset+i] = h.counts[2*i] + h.counts[2*i+1]
+	}
+	h.counts = merged
+	h.width *= 2
+}
+
+// Reset removes all the observations
+func (h *Histogram) Reset() {
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.underflow = 0
+	h.overflow = 0
+	h.count = 0
+	if h.dynamic {
+		h.width = 0
+		h.buffer = nil
+	}
+}
+
+// GetId returns the id of the histogram
+func (h *Histogram) GetId() string {
+	return h.id
+}
+
+// GetCount returns number of observations
+func (h *Histogram) GetCount() int64 {
+	return h.count
+}
+
+// GetBins returns the lower bounds and the counts of the bins
+func (h *Histogram) GetBins() ([]float64, []int64) {
+	h.flush()
+	bounds := make([]float64, len(h.counts))
+	for i := range bounds {
+		bounds[i] = h.lo + float64(i)*h.width
+	}
+	return bounds, append([]int64{}, h.counts...)
+}
+
+// GetUnderflow returns number of observations below the range of the fixed histogram
+func (h *Histogram) GetUnderflow() int64 {
+	return h.underflow
+}
+
+// GetOverflow returns number of observations above the range of the fixed histogram
+func (h *Histogram) GetOverflow() int64 {
+	return h.overflow
+}
+
+// GetPercentile returns the estimation of the percentile p (0..1)
+// interpolated within the bin
+func (h *Histogram) GetPercentile(p float64) float64 {
+	if p < 0 || p > 1 {
+		panic("invalid percentile")
+	}
+	if h.count == 0 {
+		return math.NaN()
+	}
+	if h.buffer != nil {
+		return Percentile(h.buffer, p)
+	}
+	target := p * float64(h.count)
+	cum := float64(h.underflow)
+	if target <= cum {
+		return h.lo
+	}
+	for i, c := range h.counts {
+		if cum+float64(c) >= target && c > 0 {
+			return h.lo + h.width*(float64(i)+(target-cum)/float64(c))
+		}
+		cum += float64(c)
+	}
+	return h.lo + h.width*float64(len(h.counts))
+}
+
+// flush places the buffered observations of the dynamic histogram into the bins
+func (h *Histogram) flush() {
+	if h.buffer == nil {
+		return
+	}
+	buffer := h.buffer
+	h.buffer = nil
+	h.count -= int64(len(buffer))
+	min, max := MinMax(buffer)
+	h.lo = min
+	h.width = (max - min) / float64(len(h.counts))
+	if h.width == 0 {
+		h.width = 1
+	}
+	// the maximum shall be inside the range
+	h.width = math.Nextafter(h.width, math.Inf(1))
+	for _, v := range buffer {
+		h.count++
+		h.counts[h.index(v)]++
+	}
+}
+
+// Print outputs the text histogram
+func (h *Histogram) Print(w io.Writer) {
+	const barWidth = 50
+	h.flush()
+	fmt.Fprintf(w, "Histogram %s (%d observations)\n", h.id, h.count)
+	if h.count == 0 {
+		return
+	}
+	max := int64(0)
+	for _, c := range h.counts {
+		if c > max {
+			max = c
+		}
+	}
+	if h.underflow > 0 {
+		fmt.Fprintf(w, "%10s %10.3f\t%6d\n", "<", h.lo, h.underflow)
+	}
+	for i, c := range h.counts {
+		bar := 0
+		if max > 0 {
+			bar = int(float64(c) * barWidth / float64(max))
+		}
+		lo := h.lo + float64(i)*h.width
+		fmt.Fprintf(w, "%10.3f %10.3f\t%6d\t%s\n", lo, lo+h.width, c, strings.Repeat("#", bar))
+	}
+	if h.overflow > 0 {
+		fmt.Fprintf(w, "%10s %10.3f\t%6d\n", ">=", h.lo+h.width*float64(len(h.counts)), h.overflow)
+	}
+}
+
+// PrintHistogram outputs the text histogram to the standard output
+func (h *Histogram) PrintHistogram() {
+	h.Print(os.Stdout)
+}
+
+// P2Quantile is the streaming estimator of the quantile
+type P2Quantile struct {
+	p       float64
+	count   int
+	heights [5]float64
+	pos     [5]float64
+	desired [5]float64
+	incr    [5]float64
+}
+
+// NewP2Quantile creates the estimator of the quantile p (0..1)
+func NewP2Quantile(p float64) *P2Quantile {
+	if p <= 0 || p >= 1 {
+		panic("invalid percentile")
+	}
+	q := &P2Quantile{p: p}
+	q.Reset()
+	return q
+}
+
+// Reset removes all the observations
+func (q *P2Quantile) Reset() {
+	p := q.p
+	q.count = 0
+	q.pos = [5]float64{1, 2, 3, 4, 5}
+	q.desired = [5]float64{1, 1 + 2*p, 1 + 4*p, 3 + 2*p, 5}
+	q.incr = [5]float64{0, p / 2, p, (1 + p) / 2, 1}
+}
+
+// GetP returns the quantile probability
+func (q *P2Quantile) GetP() float64 {
+	return q.p
+}
+
+// Add adds the observation
+func (q *P2Quantile) Add(x float64) {
+	if q.count < 5 {
+		q.heights[q.count] = x
+		q.count++
+		if q.count == 5 {
+			sort.Float64s(q.heights[:])
+		}
+		return
+	}
+	q.count++
+	var k int
+	switch {
+	case x < q.heights[0]:
+		q.heights[0] = x
+		k = 0
+	case x >= q.heights[4]:
+		q.heights[4] = x
+		k = 3
+	default:
+		for k = 0; k < 3; k++ {
+			if x < q.heights[k+1] {
+				break
+			}
+		}
+	}
+	for i := k + 1; i < 5; i++ {
+		q.pos[i]++
+	}
+	for i := range q.desired {
+		q.desired[i] += q.incr[i]
+	}
+	for i := 1; i < 4; i++ {
+		d := q.desired[i] - q.pos[i]
+		if (d >= 1 && q.pos[i+1]-q.pos[i] > 1) || (d <= -1 && q.pos[i-1]-q.pos[i] < -1) {
+			s := 1.
+			if d < 0 {
+				s = -1
+			}
+			h := q.parabolic(i, s)
+			if q.heights[i-1] < h && h < q.heights[i+1] {
+				q.heights[i] = h
+			} else {
+				q.heights[i] = q.linear(i, s)
+			}
+			q.pos[i] += s
+		}
+	}
+}
+
+func (q *P2Quantile) parabolic(i int, d float64) float64 {
+	n := q.pos
+	h := q.heights
+	return h[i] + d/(n[i+1]-n[i-1])*((n[i]-n[i-1]+d)*(h[i+1]-h[i])/(n[i+1]-n[i])+
+		(n[i+1]-n[i]-d)*(h[i]-h[i-1])/(n[i]-n[i-1]))
+}
+
+func (q *P2Quantile) linear(i int, d float64) float64 {
+	j := i + int(d)
+	return q.heights[i] + d*(q.heights[j]-q.heights[i])/(q.pos[j]-q.pos[i])
+}
+
+// GetCount returns number of observations
+func (q *P2Quantile) GetCount() int {
+	return q.count
+}
+
+// Get returns the estimation of the quantile
+func (q *P2Quantile) Get() float64 {
+	if q.count == 0 {
+		return math.NaN()
+	}
+	if q.count < 5 {
+		return Percentile(q.heights[:q.count], q.p)
+	}
+	return q.heights[2]
+}
+
+// Percentile returns the percentile p (0..1) of the sample
+// with the linear interpolation between the order statistics
+func Percentile(nums []float64, p float64) float64 {
+	if p < 0 || p > 1 {
+		panic("invalid percentile")
+	}
+	if len(nums) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64{}, nums...)
+	sort.Float64s(sorted)
+	h := p * float64(len(sorted)-1)
+	i := int(math.Floor(h))
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (h-float64(i))*(sorted[i+1]-sorted[i])
+}
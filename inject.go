@@ -0,0 +1,165 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// The state of the model is changed only by the goroutine of the active runner
+// and by the control loop. The external goroutines (live inputs, UI, network servers)
+// change the model by injecting the functions into the mailbox of the simulation.
+// The control loop executes the injected functions in the order of injection:
+// before selecting the next runner to be activated, while sleeping in the real-time mode
+// and, if the keep alive mode is set, while waiting for the events when no runner is left.
+
+package godes
+
+import (
+	"math"
+	"sync"
+)
+
+// mailbox keeps the functions injected by the external goroutines
+type mailbox struct {
+	mailboxMu   sync.Mutex
+	injected    []func()
+	keepAlive   bool
+	finished    bool
+	wakeChannel chan bool
+}
+
+// Inject sends the function to be executed by the control loop of the model.
+// It returns false if the model finished
+func Inject(f func(sim *Simulation)) bool {
+	return defaultSimulation.Inject(f)
+}
+
+// InjectAndWait sends the function to be executed by the control loop of the model
+// and waits until it is executed. It returns false if the model finished
+func InjectAndWait(f func(sim *Simulation)) bool {
+	return defaultSimulation.InjectAndWait(f)
+}
+
+// SetKeepAlive sets the keep alive mode of the model
+func SetKeepAlive(keepAlive bool) {
+	defaultSimulation.SetKeepAlive(keepAlive)
+}
+
+// Inject sends the function to be executed by the control loop of the simulation.
+// It can be called from any goroutine and returns false if the simulation finished.
+// In the real-time mode the function is executed at the simulation time corresponding
+// to the wall time of the injection.
+// The function shall not block: it can add the runners, set the controls and
+// place the objects into the queues
+func (sim *Simulation) Inject(f func(sim *Simulation)) bool {
+	if f == nil {
+		panic("function is nil")
+	}
+	if sim.mdl == nil {
+		panic("model is nil")
+	}
+	return sim.mdl.post(func() { f(sim) })
+}
+
+// InjectAndWait sends the function to be executed by the control loop of the simulation
+// and waits until it is executed. It returns false if the simulation finished.
+// It shall not be called from the runners
+func (sim *Simulation) InjectAndWait(f func(sim *Simulation)) bool {
+	if f == nil {
+		panic("function is nil")
+	}
+	executed := make(chan bool)
+	if !sim.Inject(func(sim *Simulation) {
+		f(sim)
+		close(executed)
+	}) {
+		return false
+	}
+	select {
+	case <-executed:
+		return true
+	case <-sim.mdl.done:
+		// the function could be executed right before the end
+		select {
+		case <-executed:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// SetKeepAlive sets the keep alive mode. In the keep alive mode the simulation does not finish
+// when no runner is left; it waits for the injected functions until the mode is reset.
+// It can be called from any goroutine
+func (sim *Simulation) SetKeepAlive(keepAlive bool) {
+	if sim.mdl == nil {
+		sim.createModel(nil)
+	}
+	mdl := sim.mdl
+	mdl.mailboxMu.Lock()
+	mdl.keepAlive = keepAlive
+	mdl.mailboxMu.Unlock()
+	mdl.wake()
+}
+
+// post adds the function into the mailbox
+func (mdl *model) post(f func()) bool {
+	mdl.mailboxMu.Lock()
+	if mdl.finished {
+		mdl.mailboxMu.Unlock()
+		return false
+	}
+	mdl.injected = append(mdl.injected, f)
+	mdl.mailboxMu.Unlock()
+	mdl.wake()
+	return true
+}
+
+// wake signals the control loop without blocking
+func (mdl *model) wake() {
+	select {
+	case mdl.wakeChannel <- true:
+	default:
+	}
+}
+
+// drainMailbox executes the injected functions.
+// It returns true if any function was executed
+func (mdl *model) drainMailbox() bool {
+	mdl.mailboxMu.Lock()
+	injected := mdl.injected
+	mdl.injected = nil
+	mdl.mailboxMu.Unlock()
+	mdl.injections += len(injected)
+	for _, f := range injected {
+		f()
+	}
+	return len(injected) > 0
+}
+
+// idle is called by the control loop when no runner is left.
+// It returns true if the injected functions were executed and false if the simulation finished
+func (mdl *model) idle() bool {
+	for {
+		mdl.mailboxMu.Lock()
+		pending := len(mdl.injected) > 0
+		if !pending && !mdl.keepAlive {
+			mdl.finished = true
+			mdl.mailboxMu.Unlock()
+			return false
+		}
+		mdl.mailboxMu.Unlock()
+		if !pending {
+			<-mdl.wakeChannel
+		}
+		if mdl.timeScale > 0 {
+			mdl.anchorWallClock()
+			mdl.advanceToWallClock(math.Inf(1))
+		}
+		if mdl.drainMailbox() {
+			return true
+		}
+	}
+}
@@ -14,137 +14,180 @@ package godes
 
 import (
 	"container/list"
-	"fmt"
-	//"sync"
+	"log/slog"
+	"sort"
 	"time"
 )
 
-const simulationSecondScale = 100
-const rUNNER_STATE_READY = 0
-const rUNNER_STATE_ACTIVE = 1
-const rUNNER_STATE_WAITING_COND = 2
-const rUNNER_STATE_SCHEDULED = 3
-const rUNNER_STATE_INTERRUPTED = 4
-const rUNNER_STATE_TERMINATED = 5
+// WaitUntilDone stops the main goroutine and waits
+// until all the runners finished executing the Run()
+func WaitUntilDone() {
+	defaultSimulation.WaitUntilDone()
+}
 
-var modl *model
-var stime float64 = 0
+//AddRunner adds the runner obejct into model
+func AddRunner(runner RunnerInterface) {
+	defaultSimulation.AddRunner(runner)
+}
+
+//Interrupt holds the runner execution
+func Interrupt(runner RunnerInterface) {
+	defaultSimulation.Interrupt(runner)
+}
+
+//Resume restarts the runner execution
+func Resume(runner RunnerInterface, timeChange float64) {
+	defaultSimulation.Resume(runner, timeChange)
+}
+
+//Run starts the simulation model.
+// Must be called explicitly.
+func Run() {
+	defaultSimulation.Run()
+}
+
+//Advance the simulation time
+func Advance(interval float64) {
+	defaultSimulation.Advance(interval)
+}
+
+// Verbose sets the model in the verbose mode.
+// The engine events are written into the default slog logger (see SetTracer)
+func Verbose(v bool) {
+	defaultSimulation.Verbose(v)
+}
+
+// Clear the model between the runs
+func Clear() {
+	defaultSimulation.Clear()
+}
+
+// GetSystemTime retuns the current simulation time
+func GetSystemTime() float64 {
+	return defaultSimulation.GetSystemTime()
+}
+
+// Yield stops the runner for short time
+func Yield() {
+	defaultSimulation.Yield()
+}
 
 // WaitUntilDone stops the main goroutine and waits
 // until all the runners finished executing the Run()
-func WaitUntilDone() {
-	if modl == nil {
+func (sim *Simulation) WaitUntilDone() {
+	if sim.mdl == nil {
 		panic(" not initilized")
 	}
-	modl.waitUntillDone()
+	sim.mdl.waitUntillDone()
 }
 
 //AddRunner adds the runner obejct into model
-func AddRunner(runner RunnerInterface) {
+func (sim *Simulation) AddRunner(runner RunnerInterface) {
 	if runner == nil {
 		panic("runner is nil")
 	}
-	if modl == nil {
-		createModel(false)
+	if sim.mdl == nil {
+		sim.createModel(nil)
 	}
-	modl.add(runner)
+	sim.mdl.add(runner)
 }
 
 //Interrupt holds the runner execution
-func Interrupt(runner RunnerInterface) {
+func (sim *Simulation) Interrupt(runner RunnerInterface) {
 	if runner == nil {
 		panic("runner is nil")
 	}
-	if modl == nil {
+	if sim.mdl == nil {
 		panic("model is nil")
 	}
-	modl.interrupt(runner)
+	sim.mdl.interrupt(runner)
 }
 
 //Resume restarts the runner execution
-func Resume(runner RunnerInterface, timeChange float64) {
+func (sim *Simulation) Resume(runner RunnerInterface, timeChange float64) {
 	if runner == nil {
 		panic("runner is nil")
 	}
-	if modl == nil {
+	if sim.mdl == nil {
 		panic("model is nil")
 	}
-	modl.resume(runner, timeChange)
+	sim.mdl.resume(runner, timeChange)
 }
 
 //Run starts the simulation model.
 // Must be called explicitly.
-func Run() {
-	if modl == nil {
-		createModel(false)
+func (sim *Simulation) Run() {
+	if sim.mdl == nil {
+		sim.createModel(nil)
 	}
 	//assuming that it comes from the main go routine
-	if modl.activeRunner == nil {
+	if sim.mdl.activeRunner == nil {
 		panic("runner is nil")
 	}
 
-	if modl.activeRunner.getInternalId() != 0 {
+	if sim.mdl.activeRunner.getInternalId() != 0 {
 		panic("it comes from not from the main go routine")
 	}
 
-	modl.simulationActive = true
-	modl.control()
+	sim.mdl.simulationActive = true
+	sim.mdl.control()
 
 }
 
 //Advance the simulation time
-func Advance(interval float64) {
-	if modl == nil {
-		createModel(false)
+func (sim *Simulation) Advance(interval float64) {
+	if sim.mdl == nil {
+		sim.createModel(nil)
 	}
-	modl.advance(interval)
+	sim.mdl.advance(interval)
 }
 
-// Verbose sets the model in the verbose mode
-func Verbose(v bool) {
-	if modl == nil {
-		createModel(v)
+// Verbose sets the model in the verbose mode.
+// The engine events are written into the default slog logger (see SetTracer)
+func (sim *Simulation) Verbose(v bool) {
+	if v {
+		sim.SetTracer(NewSlogTracer(slog.Default(), slog.LevelInfo))
+	} else {
+		sim.SetTracer(nil)
 	}
-	modl.DEBUG = v
 }
 
 // Clear the model between the runs
-func Clear() {
-	if modl == nil {
+func (sim *Simulation) Clear() {
+	if sim.mdl == nil {
 		panic(" No model exist")
 	} else {
-
-		stime = 0
-		modl = newModel(modl.DEBUG)
-		//model.simulationActive = true
-		//model.control()
+		mdl := newModel(sim.mdl.tracer)
+		mdl.observers = sim.mdl.observers
+		mdl.calendar = &sim.calendar
+		sim.mdl = mdl
 	}
 }
 
 // GetSystemTime retuns the current simulation time
-func GetSystemTime() float64 {
-	return stime
+func (sim *Simulation) GetSystemTime() float64 {
+	if sim.mdl == nil {
+		return 0
+	}
+	return sim.mdl.stime
 }
 
 // Yield stops the runner for short time
-func Yield() {
-	Advance(0.01)
+func (sim *Simulation) Yield() {
+	sim.Advance(0.01)
 }
 
 // createModel
-func createModel(verbose bool) {
-	if modl != nil {
+func (sim *Simulation) createModel(tracer Tracer) {
+	if sim.mdl != nil {
 		panic("model is already active")
 	}
-	stime = 0
-	modl = newModel(verbose)
-	//model.simulationActive = true
-	//model.control()
+	sim.mdl = newModel(tracer)
+	sim.mdl.calendar = &sim.calendar
 	//assuming that it comes from the main go routine
 }
 
 type model struct {
-	//mu                  sync.RWMutex
 	activeRunner        RunnerInterface
 	movingList          *list.List
 	scheduledList       *list.List
@@ -154,22 +197,35 @@ type model struct {
 	terminatedList      *list.List
 	currentId           int
 	controlChannel      chan int
+	done                chan bool
 	simulationActive    bool
-	DEBUG               bool
+	stime               float64
+	// others is the number of the live runners other than the daemons, including the main goroutine
+	others              int
+	tracer              Tracer
+	calendar            *calendar
+	observers           observers
+	debugger
+	clock
+	mailbox
+	journal
 }
 
 //newModel initilizes the model
-func newModel(verbose bool) *model {
+func newModel(tracer Tracer) *model {
 
 	var ball *Runner = newRunner()
 	ball.channel = make(chan int)
 	ball.markTime = time.Now()
 	ball.internalId = 0
-	ball.state = rUNNER_STATE_ACTIVE //that is bypassing READY
+	ball.state = RunnerActive //that is bypassing READY
 	ball.priority = 100
 	ball.setMarkTime(time.Now())
 	var runner RunnerInterface = ball
-	mdl := model{activeRunner: runner, controlChannel: make(chan int), DEBUG: verbose, simulationActive: false}
+	mdl := model{activeRunner: runner, controlChannel: make(chan int), done: make(chan bool), tracer: tracer, simulationActive: false, others: 1}
+	mdl.pauseChannel = make(chan bool)
+	mdl.wakeChannel = make(chan bool, 1)
+	mdl.resumeChannel = make(chan bool)
 	mdl.addToMovingList(runner)
 	return &mdl
 }
@@ -177,10 +233,11 @@ func newModel(verbose bool) *model {
 func (mdl *model) advance(interval float64) bool {
 
 	ch := mdl.activeRunner.getChannel()
-	mdl.activeRunner.setMovingTime(stime + interval)
-	mdl.activeRunner.setState(rUNNER_STATE_SCHEDULED)
+	mdl.activeRunner.setMovingTime(mdl.stime + interval)
+	mdl.activeRunner.setState(RunnerScheduled)
 	mdl.removeFromMovingList(mdl.activeRunner)
 	mdl.addToSchedulledList(mdl.activeRunner)
+	mdl.trace(EventRunnerScheduled, mdl.activeRunner)
 	//restart control channel and freez
 	mdl.controlChannel <- 100
 	<-ch
@@ -189,33 +246,38 @@ func (mdl *model) advance(interval float64) bool {
 
 func (mdl *model) waitUntillDone() {
 
+	if mdl.detached {
+		// the model was executed by the debugging functions
+		mdl.breaksDisabled = true
+		if mdl.paused {
+			mdl.resumeExecution()
+		}
+		<-mdl.done
+		return
+	}
 	if mdl.activeRunner.getInternalId() != 0 {
 		panic("waitUntillDone initiated for not main ball")
 	}
 
 	mdl.removeFromMovingList(mdl.activeRunner)
+	mdl.others--
+	mdl.wakeDaemons()
 	mdl.controlChannel <- 100
-	for {
-
-		if !modl.simulationActive {
-			break
-		} else {
-			if mdl.DEBUG {
-				fmt.Println("waiting", mdl.movingList.Len())
-			}
-			time.Sleep(time.Millisecond * simulationSecondScale)
-		}
-	}
+	<-mdl.done
 }
 
 func (mdl *model) add(runner RunnerInterface) bool {
 
 	mdl.currentId++
 	runner.setChannel(make(chan int))
-	runner.setMovingTime(stime)
+	runner.setMovingTime(mdl.stime)
 	runner.setInternalId(mdl.currentId)
-	runner.setState(rUNNER_STATE_READY)
+	runner.setState(RunnerReady)
 	mdl.addToMovingList(runner)
+	if !isDaemon(runner) {
+		mdl.others++
+	}
+	mdl.trace(EventRunnerAdded, runner)
 
 	go func() {
 		<-runner.getChannel()
@@ -225,7 +287,13 @@ func (mdl *model) add(runner RunnerInterface) bool {
 			panic("remove: activeRunner == nil")
 		}
 		mdl.removeFromMovingList(mdl.activeRunner)
-		mdl.activeRunner.setState(rUNNER_STATE_TERMINATED)
+		mdl.activeRunner.setState(RunnerTerminated)
+		if !isDaemon(mdl.activeRunner) {
+			mdl.others--
+		}
+		mdl.trace(EventRunnerTerminated, mdl.activeRunner)
+		mdl.notifyRunnerTerminated(mdl.activeRunner)
+		mdl.wakeDaemons()
 		mdl.activeRunner = nil
 		mdl.controlChannel <- 100
 	}()
@@ -235,24 +303,26 @@ func (mdl *model) add(runner RunnerInterface) bool {
 
 func (mdl *model) interrupt(runner RunnerInterface) {
 
-	if runner.getState() != rUNNER_STATE_SCHEDULED {
-		panic("It is not  rUNNER_STATE_SCHEDULED")
+	if runner.getState() != RunnerScheduled {
+		panic("It is not  RunnerScheduled")
 	}
 	mdl.removeFromSchedulledList(runner)
-	runner.setState(rUNNER_STATE_INTERRUPTED)
+	runner.setState(RunnerInterrupted)
 	mdl.addToInterruptedMap(runner)
+	mdl.trace(EventRunnerInterrupted, runner)
 
 }
 
 func (mdl *model) resume(runner RunnerInterface, timeChange float64) {
-	if runner.getState() != rUNNER_STATE_INTERRUPTED {
-		panic("It is not  rUNNER_STATE_INTERRUPTED")
+	if runner.getState() != RunnerInterrupted {
+		panic("It is not  RunnerInterrupted")
 	}
 	mdl.removeFromInterruptedMap(runner)
-	runner.setState(rUNNER_STATE_SCHEDULED)
+	runner.setState(RunnerScheduled)
 	runner.setMovingTime(runner.getMovingTime() + timeChange)
 	//mdl.addToMovingList(runner)
 	mdl.addToSchedulledList(runner)
+	mdl.trace(EventRunnerResumed, runner)
 
 }
 
@@ -266,11 +336,12 @@ func (mdl *model) booleanControlWait(b *BooleanControl, val bool) {
 
 	mdl.removeFromMovingList(mdl.activeRunner)
 
-	mdl.activeRunner.setState(rUNNER_STATE_WAITING_COND)
+	mdl.activeRunner.setState(RunnerWaiting)
 	mdl.activeRunner.setWaitingForBool(val)
 	mdl.activeRunner.setWaitingForBoolControl(b)
 
 	mdl.addToWaitingConditionMap(mdl.activeRunner)
+	mdl.trace(EventRunnerWaiting, mdl.activeRunner)
 	mdl.controlChannel <- 100
 	<-ch
 
@@ -278,8 +349,8 @@ func (mdl *model) booleanControlWait(b *BooleanControl, val bool) {
 
 func (mdl *model) booleanControlWaitAndTimeout(b *BooleanControl, val bool, timeout float64) {
 
-	ri := &TimeoutRunner{&Runner{}, mdl.activeRunner, timeout}
-	AddRunner(ri)
+	ri := &TimeoutRunner{&Runner{}, mdl.activeRunner, timeout, mdl}
+	mdl.add(ri)
 	mdl.activeRunner.setWaitingForBoolControlTimeoutId(ri.getInternalId())
 	mdl.booleanControlWait(b, val)
 
@@ -303,52 +374,92 @@ func (mdl *model) control() bool {
 
 	go func() {
 		var runner RunnerInterface
+		reselectSwt := false
 		for {
-			<-mdl.controlChannel
+			if !reselectSwt {
+				<-mdl.controlChannel
+			}
+			reselectSwt = false
+			mdl.drainMailbox()
 			if mdl.waitingConditionMap != nil && len(mdl.waitingConditionMap) > 0 {
+				// the runner with the lowest id is released first,
+				// so the execution does not depend on the map iteration order
+				found := -1
 				for key, temp := range mdl.waitingConditionMap {
 					if temp.getWaitingForBoolControl() == nil {
 						panic("  no BoolControl")
 					}
-					if temp.getWaitingForBool() == temp.getWaitingForBoolControl().GetState() {
-						temp.setState(rUNNER_STATE_READY)
-						temp.setWaitingForBoolControl(nil)
-						temp.setWaitingForBoolControlTimeoutId(-1)
-						mdl.addToMovingList(temp)
-						delete(mdl.waitingConditionMap, key)
-						break
+					if temp.getWaitingForBool() == temp.getWaitingForBoolControl().GetState() && (found < 0 || key < found) {
+						found = key
 					}
 				}
+				if found >= 0 {
+					temp := mdl.waitingConditionMap[found]
+					temp.setState(RunnerReady)
+					temp.setWaitingForBoolControl(nil)
+					temp.setWaitingForBoolControlTimeoutId(-1)
+					mdl.addToMovingList(temp)
+					delete(mdl.waitingConditionMap, found)
+				}
 			}
 
 			//finding new runner
 			runner = nil
+			pausedSwt := false
 			if mdl.movingList != nil && mdl.movingList.Len() > 0 {
 				runner = mdl.getFromMovingList()
 			}
 			if runner == nil && mdl.scheduledList != nil && mdl.scheduledList.Len() > 0 {
+				next := mdl.scheduledList.Back().Value.(RunnerInterface).getMovingTime()
+				if mdl.breakOnTime(next) {
+					mdl.pause()
+					mdl.resetWallClock()
+					pausedSwt = true
+				}
+				if mdl.waitWallClock(next) {
+					// the external event was injected before the next activation
+					reselectSwt = true
+					continue
+				}
 				runner = mdl.getFromSchedulledList()
-				if runner.getMovingTime() < stime {
+				if runner.getMovingTime() < mdl.stime {
 					panic("control is seting simulation time in the past")
-				} else {
-					stime = runner.getMovingTime()
+				} else if runner.getMovingTime() > mdl.stime {
+					from := mdl.stime
+					mdl.stime = runner.getMovingTime()
+					mdl.notifyTimeAdvance(from, mdl.stime)
 				}
 				mdl.addToMovingList(runner)
 			}
 			if runner == nil {
+				if mdl.idle() {
+					// the external event was injected into the idle model
+					reselectSwt = true
+					continue
+				}
 				break
 			}
+			if !pausedSwt && mdl.breakBeforeActivation() {
+				mdl.next = runner
+				mdl.pause()
+				mdl.resetWallClock()
+				mdl.next = nil
+			}
+			if mdl.stepping {
+				mdl.steps--
+			}
+			mdl.record(runner)
 			//restarting
 			mdl.activeRunner = runner
-			mdl.activeRunner.setState(rUNNER_STATE_ACTIVE)
+			mdl.activeRunner.setState(RunnerActive)
 			runner.setWaitingForBoolControl(nil)
+			mdl.trace(EventRunnerActivated, runner)
 			mdl.activeRunner.getChannel() <- -1
 
 		}
-		if mdl.DEBUG {
-			fmt.Println("Finished")
-		}
+		mdl.trace(EventSimulationFinished, nil)
 		mdl.simulationActive = false
+		close(mdl.done)
 	}()
 
 	return true
@@ -368,9 +479,6 @@ Balls with identical priority values are sorted according to the FIFO principle.
 */
 func (mdl *model) addToMovingList(runner RunnerInterface) bool {
 
-	if mdl.DEBUG {
-		fmt.Printf("addToMovingList %v\n", runner)
-	}
 
 	if mdl.movingList == nil {
 		mdl.movingList = list.New()
@@ -397,10 +505,6 @@ func (mdl *model) getFromMovingList() RunnerInterface {
 	if mdl.movingList == nil {
 		panic("MovingList was not initilized")
 	}
-	if mdl.DEBUG {
-		runner := mdl.movingList.Front().Value.(RunnerInterface)
-		fmt.Printf("getFromMovingList %v\n", runner)
-	}
 	runner := mdl.movingList.Front().Value.(RunnerInterface)
 	mdl.movingList.Remove(mdl.movingList.Front())
 	return runner
@@ -413,9 +517,6 @@ func (mdl *model) removeFromMovingList(runner RunnerInterface) {
 		panic("MovingList was not initilized")
 	}
 
-	if mdl.DEBUG {
-		fmt.Printf("removeFromMovingList %v\n", runner)
-	}
 	var found bool
 	for e := mdl.movingList.Front(); e != nil; e = e.Next() {
 		if e.Value == runner {
@@ -461,14 +562,6 @@ func (mdl *model) addToSchedulledList(runner RunnerInterface) bool {
 		mdl.scheduledList.PushFront(runner)
 	}
 
-	if mdl.DEBUG {
-		fmt.Println("===")
-		fmt.Printf("addToSchedulledList %v\n", runner)
-		for element := mdl.scheduledList.Front(); element != nil; element = element.Next() {
-			fmt.Printf("elem %v\n", element.Value.(RunnerInterface))
-		}
-		fmt.Println("===")
-	}
 	return true
 }
 
@@ -476,10 +569,6 @@ func (mdl *model) getFromSchedulledList() RunnerInterface {
 	if mdl.scheduledList == nil {
 		panic(" SchedulledList was not initilized")
 	}
-	if mdl.DEBUG {
-		runner := mdl.scheduledList.Back().Value.(RunnerInterface)
-		fmt.Printf("getFromSchedulledList %v\n", runner)
-	}
 	runner := mdl.scheduledList.Back().Value.(RunnerInterface)
 	mdl.scheduledList.Remove(mdl.scheduledList.Back())
 	return runner
@@ -489,9 +578,6 @@ func (mdl *model) removeFromSchedulledList(runner RunnerInterface) {
 	if mdl.scheduledList == nil {
 		panic("schedulledList was not initilized")
 	}
-	if modl.DEBUG {
-		fmt.Printf("removeFrom schedulledListt %v\n", runner)
-	}
 	var found bool
 	for e := mdl.scheduledList.Front(); e != nil; e = e.Next() {
 		if e.Value == runner {
@@ -512,9 +598,6 @@ func (mdl *model) addToWaitingConditionMap(runner RunnerInterface) bool {
 		panic(" addToWaitingConditionMap - no control ")
 	}
 
-	if mdl.DEBUG {
-		fmt.Printf("addToWaitingConditionMap %v\n", runner)
-	}
 
 	if mdl.waitingConditionMap == nil {
 		mdl.waitingConditionMap = make(map[int]RunnerInterface)
@@ -526,9 +609,6 @@ func (mdl *model) addToWaitingConditionMap(runner RunnerInterface) bool {
 
 func (mdl *model) addToInterruptedMap(runner RunnerInterface) bool {
 
-	if mdl.DEBUG {
-		fmt.Printf("addToInterruptedMap %v\n", runner)
-	}
 	if mdl.interruptedMap == nil {
 		mdl.interruptedMap = make(map[int]RunnerInterface)
 	}
@@ -539,9 +619,6 @@ func (mdl *model) addToInterruptedMap(runner RunnerInterface) bool {
 
 func (mdl *model) removeFromInterruptedMap(runner RunnerInterface) bool {
 
-	if mdl.DEBUG {
-		fmt.Printf("removeFromInterruptedMap %v\n", runner)
-	}
 
 	_, ok := mdl.interruptedMap[runner.getInternalId()]
 
@@ -552,3 +629,66 @@ func (mdl *model) removeFromInterruptedMap(runner RunnerInterface) bool {
 	}
 	return true
 }
+
+// daemon is the internal runner which serves the other runners (schedules, failures)
+// and terminates when no other runner is left in the model
+type daemon interface {
+	daemon()
+}
+
+// wakeDaemons activates the daemons at the current time when no other runner is left,
+// so they terminate without advancing the simulation time.
+// The scheduled, waiting and interrupted daemons are activated in the order of the ids
+func (mdl *model) wakeDaemons() {
+	if mdl.hasOtherRunners() {
+		return
+	}
+	runners := []RunnerInterface{}
+	if mdl.scheduledList != nil {
+		for e := mdl.scheduledList.Front(); e != nil; e = e.Next() {
+			runners = append(runners, e.Value.(RunnerInterface))
+		}
+	}
+	for _, runner := range mdl.waitingConditionMap {
+		runners = append(runners, runner)
+	}
+	for _, runner := range mdl.interruptedMap {
+		runners = append(runners, runner)
+	}
+	sort.Slice(runners, func(i, j int) bool {
+		return runners[i].getInternalId() < runners[j].getInternalId()
+	})
+	for _, runner := range runners {
+		if !isDaemon(runner) {
+			continue
+		}
+		switch runner.getState() {
+		case RunnerScheduled:
+			mdl.interrupt(runner)
+			mdl.resume(runner, mdl.stime-runner.getMovingTime())
+		case RunnerInterrupted:
+			mdl.resume(runner, mdl.stime-runner.getMovingTime())
+		case RunnerWaiting:
+			runner.setState(RunnerReady)
+			runner.setWaitingForBoolControl(nil)
+			runner.setWaitingForBoolControlTimeoutId(-1)
+			mdl.addToMovingList(runner)
+			delete(mdl.waitingConditionMap, runner.getInternalId())
+		}
+	}
+}
+
+// isDaemon returns true if the runner is not counted as the other runner:
+// the daemon or the timeout of the control
+func isDaemon(runner RunnerInterface) bool {
+	switch runner.(type) {
+	case daemon, *TimeoutRunner:
+		return true
+	}
+	return false
+}
+
+// hasOtherRunners returns true if the model contains runners other than the daemons
+func (mdl *model) hasOtherRunners() bool {
+	return mdl.others > 0
+}
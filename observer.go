@@ -0,0 +1,96 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// The observers are the functions subscribed to the changes of the simulation,
+// queues and controls. They are invoked synchronously in the simulation order,
+// so the statistics can be sampled outside the Run() of the runners.
+// The observers shall not call the functions which block the runner
+// (Advance, Wait, Yield, ...).
+
+package godes
+
+// observers of the model
+type observers struct {
+	runnerTerminated []func(runner RunnerInterface)
+	timeAdvance      []func(from float64, to float64)
+}
+
+// OnRunnerTerminated subscribes f to the termination of the runners of the model
+func OnRunnerTerminated(f func(runner RunnerInterface)) {
+	defaultSimulation.OnRunnerTerminated(f)
+}
+
+// OnTimeAdvance subscribes f to the advance of the simulation time of the model
+func OnTimeAdvance(f func(from float64, to float64)) {
+	defaultSimulation.OnTimeAdvance(f)
+}
+
+// OnRunnerTerminated subscribes f to the termination of the runners of the simulation.
+// f is called after the Run() of the runner returns
+func (sim *Simulation) OnRunnerTerminated(f func(runner RunnerInterface)) {
+	if f == nil {
+		panic("observer is nil")
+	}
+	if sim.mdl == nil {
+		sim.createModel(nil)
+	}
+	sim.mdl.observers.runnerTerminated = append(sim.mdl.observers.runnerTerminated, f)
+}
+
+// OnTimeAdvance subscribes f to the advance of the simulation time.
+// f is called before the runner scheduled at the new time is activated
+func (sim *Simulation) OnTimeAdvance(f func(from float64, to float64)) {
+	if f == nil {
+		panic("observer is nil")
+	}
+	if sim.mdl == nil {
+		sim.createModel(nil)
+	}
+	sim.mdl.observers.timeAdvance = append(sim.mdl.observers.timeAdvance, f)
+}
+
+func (mdl *model) notifyRunnerTerminated(runner RunnerInterface) {
+	for _, f := range mdl.observers.runnerTerminated {
+		f(runner)
+	}
+}
+
+func (mdl *model) notifyTimeAdvance(from float64, to float64) {
+	for _, f := range mdl.observers.timeAdvance {
+		f(from, to)
+	}
+}
+
+// OnQueueChange subscribes f to the changes of the queue.
+// f is called after an object is placed into or removed from the queue and after Clear
+func (q *Queue) OnQueueChange(f func(q *Queue)) {
+	if f == nil {
+		panic("observer is nil")
+	}
+	q.onChange = append(q.onChange, f)
+}
+
+func (q *Queue) notifyChange() {
+	for _, f := range q.onChange {
+		f(q)
+	}
+}
+
+// OnControlSet subscribes f to the changes of the control value
+func (bc *BooleanControl) OnControlSet(f func(bc *BooleanControl, state bool)) {
+	if f == nil {
+		panic("observer is nil")
+	}
+	bc.onSet = append(bc.onSet, f)
+}
+
+func (bc *BooleanControl) notifySet() {
+	for _, f := range bc.onSet {
+		f(bc, bc.state)
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Optimize searches for the values of the decision variables with the best
+// average of the measure. Every candidate point is evaluated by the replication
+// runner with the common random numbers, so the differences between the points
+// are not hidden by the noise of the random streams.
+// The built-in strategies are random search, simulated annealing and Nelder-Mead.
+// The points which violate the constraints are never evaluated.
+
+package godes
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Variable is the decision variable with the range [Min, Max].
+// The values of the integer variable are rounded
+type Variable struct {
+	Name    string
+	Min     float64
+	Max     float64
+	Integer bool
+}
+
+// Constraint returns true if the point is feasible
+type Constraint func(point DesignPoint) bool
+
+// Problem is the simulation-based optimization problem
+type Problem struct {
+	Variables   []Variable
+	Constraints []Constraint
+	Measures    []string
+	// Measure is the index of the objective measure
+	Measure  int
+	Minimize bool
+	// Runs is the number of replications for every evaluated point
+	Runs        int
+	Replication DesignReplication
+}
+
+// Optimizer is the search strategy
+type Optimizer interface {
+	Optimize(problem *Problem) *OptimizationResult
+}
+
+// OptimizationResult contains the best point found by the optimizer
+type OptimizationResult struct {
+	Best DesignPoint
+	// Collector contains the confirmation replications of the best point,
+	// which are independent of the replications used by the search
+	Collector *StatCollector
+	Average   float64
+	Lower     float64
+	Upper     float64
+	// Evaluations is the number of evaluated points
+	Evaluations int
+}
+
+// Optimize searches for the best point of the problem with the optimizer
+func Optimize(problem *Problem, optimizer Optimizer) *OptimizationResult {
+	if optimizer == nil {
+		panic("optimizer is nil")
+	}
+	return optimizer.Optimize(problem)
+}
+
+func (problem *Problem) validate() {
+	if problem.Replication == nil {
+		panic("replication is nil")
+	}
+	if len(problem.Variables) == 0 {
+		panic("no variables")
+	}
+	for _, v := range problem.Variables {
+		if v.Max < v.Min {
+			panic("invalid range for variable " + v.Name)
+		}
+	}
+	if problem.Measure < 0 || problem.Measure > len(problem.Measures)-1 {
+		panic("invalid index")
+	}
+	if problem.Runs < 2 {
+		panic("invalid number of runs")
+	}
+}
+
+// evaluator evaluates the points of the problem and keeps the best one.
+// The coordinates are scaled to [0, 1]
+type evaluator struct {
+	problem    *Problem
+	cache      map[string]float64
+	best       DesignPoint
+	bestValue  float64
+	collector  *StatCollector
+	evaluation int
+}
+
+func newEvaluator(problem *Problem) *evaluator {
+	problem.validate()
+	return &evaluator{problem: problem, cache: make(map[string]float64), bestValue: math.Inf(1)}
+}
+
+// point returns the design point for the scaled coordinates
+func (ev *evaluator) point(x []float64) DesignPoint {
+	p := DesignPoint{}
+	for i, v := range ev.problem.Variables {
+		value := v.Min + math.Max(0, math.Min(1, x[i]))*(v.Max-v.Min)
+		if v.Integer {
+			value = math.Max(math.Ceil(v.Min), math.Min(math.Floor(v.Max), math.Round(value)))
+		}
+		p[v.Name] = value
+	}
+	return p
+}
+
+func (ev *evaluator) key(p DesignPoint) string {
+	values := []string{}
+	for _, v := range ev.problem.Variables {
+		values = append(values, fmt.Sprint(p[v.Name]))
+	}
+	return strings.Join(values, ",")
+}
+
+func (ev *evaluator) feasible(p DesignPoint) bool {
+	for _, c := range ev.problem.Constraints {
+		if !c(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// value returns the objective for the scaled coordinates to be minimized.
+// The infeasible points have +Inf value
+func (ev *evaluator) value(x []float64) float64 {
+	p := ev.point(x)
+	k := ev.key(p)
+	if value, ok := ev.cache[k]; ok {
+		return value
+	}
+	value := math.Inf(1)
+	if ev.feasible(p) {
+		collector := Replicate(ev.problem.Runs, ev.problem.Measures, func(run int) []float64 {
+			return ev.problem.Replication(p, run)
+		})
+		ev.evaluation++
+		value = collector.GetAverage(ev.problem.Measure)
+		if !ev.problem.Minimize {
+			value = -value
+		}
+		if value < ev.bestValue {
+			ev.bestValue = value
+			ev.best = p
+			ev.collector = collector
+		}
+	}
+	ev.cache[k] = value
+	return value
+}
+
+// result evaluates the best point again with the fresh replications (the runs after
+// the ones used by the search), so the reported average is not biased by the selection
+func (ev *evaluator) result() *OptimizationResult {
+	if ev.collector == nil {
+		panic("no feasible point found")
+	}
+	problem := ev.problem
+	samples := [][]float64{}
+	for run := problem.Runs; run < 2*problem.Runs; run++ {
+		samples = append(samples, runReplication(run, false, problem.Measures, func(run int) []float64 {
+			return problem.Replication(ev.best, run)
+		}))
+	}
+	SetReplication(0, false)
+	collector := NewStatCollector(problem.Measures, samples)
+	m := problem.Measure
+	return &OptimizationResult{
+		Best:        ev.best,
+		Collector:   collector,
+		Average:     collector.GetAverage(m),
+		Lower:       collector.GetLowBoundCI(m),
+		Upper:       collector.GetUpperBoundCI(m),
+		Evaluations: ev.evaluation,
+	}
+}
+
+// randomPoint returns the random scaled coordinates
+func randomPoint(generator *rand.Rand, dim int) []float64 {
+	x := make([]float64, dim)
+	for i := range x {
+		x[i] = generator.Float64()
+	}
+	return x
+}
+
+// RandomSearch evaluates Iterations random points
+type RandomSearch struct {
+	Iterations int
+	Seed       int64
+}
+
+// Optimize implements Optimizer
+func (rs *RandomSearch) Optimize(problem *Problem) *OptimizationResult {
+	if rs.Iterations < 1 {
+		panic("invalid number of iterations")
+	}
+	ev := newEvaluator(problem)
+	generator := rand.New(rand.NewSource(rs.Seed))
+	for i := 0; i < rs.Iterations; i++ {
+		ev.value(randomPoint(generator, len(problem.Variables)))
+	}
+	return ev.result()
+}
+
+// SimulatedAnnealing moves to the random neighbour point within Step (the fraction of the ranges)
+// and accepts the worse point with probability exp(-delta/temperature).
+// The temperature starts with Temperature and is multiplied by Cooling after every iteration
+type SimulatedAnnealing struct {
+	Iterations  int
+	Temperature float64
+	Cooling     float64
+	Step        float64
+	Seed        int64
+}
+
+// Optimize implements Optimizer
+func (sa *SimulatedAnnealing) Optimize(problem *Problem) *OptimizationResult {
+	if sa.Iterations < 1 {
+		panic("invalid number of iterations")
+	}
+	if sa.Temperature <= 0 || sa.Cooling <= 0 || sa.Cooling > 1 || sa.Step <= 0 {
+		panic("invalid annealing parameters")
+	}
+	ev := newEvaluator(problem)
+	generator := rand.New(rand.NewSource(sa.Seed))
+	dim := len(problem.Variables)
+	current := randomPoint(generator, dim)
+	currentValue := ev.value(current)
+	for i := 0; i < 10*dim && math.IsInf(currentValue, 1); i++ {
+		current = randomPoint(generator, dim)
+		currentValue = ev.value(current)
+	}
+	if math.IsInf(currentValue, 1) {
+		panic("no feasible start point found")
+	}
+	temperature := sa.Temperature
+	for i := 0; i < sa.Iterations; i++ {
+		next := make([]float64, dim)
+		for j := range next {
+			next[j] = math.Max(0, math.Min(1, current[j]+sa.Step*(2*generator.Float64()-1)))
+		}
+		nextValue := ev.value(next)
+		delta := nextValue - currentValue
+		if delta <= 0 || generator.Float64() < math.Exp(-delta/temperature) {
+			current, currentValue = next, nextValue
+		}
+		temperature *= sa.Cooling
+	}
+	return ev.result()
+}
+
+// NelderMead implements the Nelder-Mead simplex search.
+// The initial simplex is built around Start (the center of the ranges if nil) with Step
+// (the fraction of the ranges). The search stops after Iterations or when the
+// values of the simplex differ by less than Tolerance
+type NelderMead struct {
+	Iterations int
+	Start      DesignPoint
+	Step       float64
+	Tolerance  float64
+}
+
+// Optimize implements Optimizer
+func (nm *NelderMead) Optimize(problem *Problem) *OptimizationResult {
+	if nm.Iterations < 1 {
+		panic("invalid number of iterations")
+	}
+	if nm.Step <= 0 {
+		panic("invalid step")
+	}
+	ev := newEvaluator(problem)
+	dim := len(problem.Variables)
+	start := make([]float64, dim)
+	for i, v := range problem.Variables {
+		start[i] = 0.5
+		if value, ok := nm.Start[v.Name]; ok && v.Max > v.Min {
+			start[i] = (value - v.Min) / (v.Max - v.Min)
+		}
+	}
+	simplex := [][]float64{start}
+	for i := 0; i < dim; i++ {
+		x := append([]float64{}, start...)
+		if x[i]+nm.Step <= 1 {
+			x[i] += nm.Step
+		} else {
+			x[i] -= nm.Step
+		}
+		simplex = append(simplex, x)
+	}
+	values := make([]float64, dim+1)
+	for i, x := range simplex {
+		values[i] = ev.value(x)
+	}
+	for iteration := 0; iteration < nm.Iterations; iteration++ {
+		// order the simplex by the values
+		for i := 1; i < len(simplex); i++ {
+			for j := i; j > 0 && values[j] < values[j-1]; j-- {
+				simplex[j], simplex[j-1] = simplex[j-1], simplex[j]
+				values[j], values[j-1] = values[j-1], values[j]
+			}
+		}
+		if math.Abs(values[dim]-values[0]) <= nm.Tolerance {
+			break
+		}
+		centroid := make([]float64, dim)
+		for _, x := range simplex[:dim] {
+			for j := range centroid {
+				centroid[j] += x[j] / float64(dim)
+			}
+		}
+		move := func(coef float64) []float64 {
+			x := make([]float64, dim)
+			for j := range x {
+				x[j] = math.Max(0, math.Min(1, centroid[j]+coef*(simplex[dim][j]-centroid[j])))
+			}
+			return x
+		}
+		reflected := move(-1)
+		reflectedValue := ev.value(reflected)
+		switch {
+		case reflectedValue < values[0]:
+			expanded := move(-2)
+			expandedValue := ev.value(expanded)
+			if expandedValue < reflectedValue {
+				simplex[dim], values[dim] = expanded, expandedValue
+			} else {
+				simplex[dim], values[dim] = reflected, reflectedValue
+			}
+		case reflectedValue < values[dim-1]:
+			simplex[dim], values[dim] = reflected, reflectedValue
+		default:
+			contracted := move(0.5)
+			contractedValue := ev.value(contracted)
+			if contractedValue < values[dim] {
+				simplex[dim], values[dim] = contracted, contractedValue
+			} else {
+				// shrink towards the best point
+				for i := 1; i <= dim; i++ {
+					for j := range simplex[i] {
+						simplex[i][j] = simplex[0][j] + 0.5*(simplex[i][j]-simplex[0][j])
+					}
+					values[i] = ev.value(simplex[i])
+				}
+			}
+		}
+	}
+	return ev.result()
+}
+
+// PrintResult prints the best point and the confidence interval of the objective
+func (result *OptimizationResult) PrintResult(problem *Problem) {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 10, 0, '\t', 0)
+	fmt.Fprintln(w, "Variable\tValue")
+	for _, v := range problem.Variables {
+		fmt.Fprintf(w, "%s\t%6.3f\n", v.Name, result.Best[v.Name])
+	}
+	fmt.Fprintf(w, "%s\t%6.3f\t[%6.3f, %6.3f]\n", problem.Measures[problem.Measure], result.Average, result.Lower, result.Upper)
+	fmt.Fprintf(w, "Evaluations\t%d\n", result.Evaluations)
+	w.Flush()
+}
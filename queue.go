@@ -22,6 +22,8 @@ type Queue struct {
 	qList     *list.List
 	qTime     *list.List
 	startTime float64
+	sim       *Simulation
+	onChange  []func(q *Queue)
 }
 
 // FIFOQueue represents a FIFO queue
@@ -46,16 +48,17 @@ func (q *Queue) Len() int {
 
 // GetAverageTime is average elapsed time for an object in the queue
 func (q *Queue) GetAverageNumber() float64 {
-	return q.sumTime / (stime - q.startTime)
+	return q.sumTime / (q.now() - q.startTime)
 }
 
 // Place adds an object to the queue
 func (q *Queue) Place(entity interface{}) {
 	q.qList.PushFront(entity)
-	q.qTime.PushFront(stime)
+	q.qTime.PushFront(q.now())
 	if q.startTime == 0 {
-		q.startTime = stime
+		q.startTime = q.now()
 	}
+	q.notifyChange()
 }
 
 // Get returns an object and removes it from the queue
@@ -75,8 +78,9 @@ func (q *Queue) Get() interface{} {
 		q.qTime.Remove(q.qTime.Front())
 	}
 
-	q.sumTime = q.sumTime + stime - timeIn
+	q.sumTime = q.sumTime + q.now() - timeIn
 	q.count++
+	q.notifyChange()
 
 	return entity
 }
@@ -102,12 +106,28 @@ func NewLIFOQueue(mid string) *LIFOQueue {
 	return &LIFOQueue{Queue{fifo: false, id: mid, qList: list.New(), qTime: list.New()}}
 }
 
+// NewFIFOQueue itializes the FIFO queue of the simulation
+func (sim *Simulation) NewFIFOQueue(mid string) *FIFOQueue {
+	return &FIFOQueue{Queue{fifo: true, id: mid, qList: list.New(), qTime: list.New(), sim: sim}}
+}
+
+// NewLIFOQueue itializes the LIFO queue of the simulation
+func (sim *Simulation) NewLIFOQueue(mid string) *LIFOQueue {
+	return &LIFOQueue{Queue{fifo: false, id: mid, qList: list.New(), qTime: list.New(), sim: sim}}
+}
+
+// now returns the simulation time of the queue
+func (q *Queue) now() float64 {
+	return getSimulation(q.sim).GetSystemTime()
+}
+
 // Clear reinitiates the queue
 func (q *Queue) Clear() {
 	q.sumTime = 0
 	q.count = 0
 	q.qList.Init()
 	q.qTime.Init()
+	q.notifyChange()
 }
 
 // NEW: Get List
@@ -19,13 +19,53 @@ package godes
 import (
 	"math"
 	"math/rand"
+	"sync/atomic"
 	//"fmt"
 )
 
-var seedCount int64 = 100000
+// sEED_COUNT is the base of the seeds of the repeatable generators
+const sEED_COUNT int64 = 100000
+
+// rUN_SEEDS is the range of the seeds of the repeatable generators for one run of the parallel replications
+const rUN_SEEDS int64 = 1 << 20
+
+// nextSeed returns the seed of the next repeatable generator of the simulation.
+// The seeds depend on the order of creation within the simulation, so every simulation
+// instance of the parallel replications gets the same seeds for the same model
+func (sim *Simulation) nextSeed() int64 {
+	return atomic.AddInt64(&sim.seedCount, 1)
+}
+
+// newDistribution returns the repeatable generator of the simulation
+// or the generator seeded with the computer time
+func (sim *Simulation) newDistribution(repetion bool) distribution {
+	if repetion {
+		return distribution{generator: rand.New(rand.NewSource(sim.nextSeed()))}
+	}
+	return distribution{generator: rand.New(rand.NewSource(GetCurComputerTime()))}
+}
 
 type distribution struct {
-	generator *rand.Rand
+	generator  *rand.Rand
+	stream     int
+	inversion  bool
+	antithetic bool
+	// set is the stream set of the stream generator, epoch is the epoch of its seed
+	set   *streamSet
+	epoch int
+}
+
+// uniform returns the next uniform value from the generator.
+// For the antithetic generator the value is 1-U
+func (d *distribution) uniform() float64 {
+	if d.set != nil && d.epoch != d.set.epoch {
+		d.reseed()
+	}
+	u := d.generator.Float64()
+	if d.antithetic {
+		return 1. - u
+	}
+	return u
 }
 
 
@@ -36,17 +76,17 @@ type UniformDistr struct {
 
 //NewUniformDistr initiats the generator for the uniform distribution
 func NewUniformDistr(repetion bool) *UniformDistr {
-	if repetion {
-		seedCount++
-		return &UniformDistr{distribution{rand.New(rand.NewSource(seedCount))}}
-	} else {
-		return &UniformDistr{distribution{rand.New(rand.NewSource(GetCurComputerTime()))}}
-	}	
+	return defaultSimulation.NewUniformDistr(repetion)
+}
+
+// NewUniformDistr initiats the generator of the simulation for the uniform distribution
+func (sim *Simulation) NewUniformDistr(repetion bool) *UniformDistr {
+	return &UniformDistr{sim.newDistribution(repetion)}
 }
 
 // Get returns new radom value from the uniform distribution generator
 func (b *UniformDistr) Get(min float64, max float64) float64 {
-	return b.generator.Float64()*(max-min) + min
+	return b.uniform()*(max-min) + min
 }
 
 // NormalDistr represents the generator for the normal distribution
@@ -56,16 +96,19 @@ type NormalDistr struct {
 
 // NewNormalDistr initiats the generator for the normal distribution
 func NewNormalDistr(repetion bool) *NormalDistr {
-	if repetion {
-		seedCount++
-		return &NormalDistr{distribution{rand.New(rand.NewSource(seedCount))}}
-	} else {
-		return &NormalDistr{distribution{rand.New(rand.NewSource(GetCurComputerTime()))}}
-	}
+	return defaultSimulation.NewNormalDistr(repetion)
+}
+
+// NewNormalDistr initiats the generator of the simulation for the normal distribution
+func (sim *Simulation) NewNormalDistr(repetion bool) *NormalDistr {
+	return &NormalDistr{sim.newDistribution(repetion)}
 }
 
 // Get returns new radom value from the normal distribution generator
 func (b *NormalDistr) Get(mean float64, sigma float64) float64 {
+	if b.inversion {
+		return normalInverse(b.uniform())*sigma + mean
+	}
 	return b.generator.NormFloat64()*sigma + mean
 }
 
@@ -77,18 +120,19 @@ type ExpDistr struct {
 //NewExpDistr initiats the generator for the exponential distribution
 // If repetition flag is true, the generator will generate the same sequences for every execution
 func NewExpDistr(repetion bool) *ExpDistr {
+	return defaultSimulation.NewExpDistr(repetion)
+}
 
-	if repetion {
-		seedCount++
-		return &ExpDistr{distribution{rand.New(rand.NewSource(seedCount))}}
-	} else {
-		return &ExpDistr{distribution{rand.New(rand.NewSource(GetCurComputerTime()))}}
-	}
-
+// NewExpDistr initiats the generator of the simulation for the exponential distribution
+func (sim *Simulation) NewExpDistr(repetion bool) *ExpDistr {
+	return &ExpDistr{sim.newDistribution(repetion)}
 }
 
 // Get returns new radom value from the exponential distribution generator
 func (b *ExpDistr) Get(lambda float64) float64 {
+	if b.inversion {
+		return expInverse(b.uniform()) / lambda
+	}
 	return b.generator.ExpFloat64() / lambda
 }
 
@@ -100,17 +144,17 @@ type TriangularDistr struct {
 //NewTriangularDistr initiats the generator for the triangular distribution
 // If repetition flag is true, the generator will generate the same sequences for every execution
 func NewTriangularDistr(repetion bool) *TriangularDistr {
-	if repetion {
-		seedCount++
-		return &TriangularDistr{distribution{rand.New(rand.NewSource(seedCount))}}
-	} else {
-		return &TriangularDistr{distribution{rand.New(rand.NewSource(GetCurComputerTime()))}}
-	}
+	return defaultSimulation.NewTriangularDistr(repetion)
+}
+
+// NewTriangularDistr initiats the generator of the simulation for the triangular distribution
+func (sim *Simulation) NewTriangularDistr(repetion bool) *TriangularDistr {
+	return &TriangularDistr{sim.newDistribution(repetion)}
 }
 
 // Get returns new radom value from the triangular distribution generator
 func (bd *TriangularDistr) Get(a float64, b float64, c float64) float64 {
-	u := bd.generator.Float64()
+	u := bd.uniform()
 	f := (c - a) / (b - a)
 	if u < f {
 		return a + math.Sqrt(u*(b-a)*(c-a))
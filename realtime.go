@@ -0,0 +1,99 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// In the real-time mode the simulation clock follows the wall clock:
+// before advancing the simulation time the control loop sleeps, so that one
+// unit of the simulation time lasts the time scale duration.
+// With zero time scale (the default) the model runs as fast as possible.
+// The functions injected by the external goroutines (see Inject) wake the sleeping
+// control loop and are executed at the simulation time corresponding
+// to the wall time of the injection.
+
+package godes
+
+import (
+	"time"
+)
+
+// clock keeps the state of the real-time mode
+type clock struct {
+	timeScale time.Duration
+	// wallOrigin is the wall time corresponding to the simulation time simOrigin
+	wallOrigin time.Time
+	simOrigin  float64
+}
+
+// SetTimeScale sets the wall duration of one unit of the simulation time.
+// Zero time scale runs the model as fast as possible
+func SetTimeScale(scale time.Duration) {
+	defaultSimulation.SetTimeScale(scale)
+}
+
+// SetTimeScale sets the wall duration of one unit of the simulation time.
+// Zero time scale runs the simulation as fast as possible
+func (sim *Simulation) SetTimeScale(scale time.Duration) {
+	if scale < 0 {
+		panic("negative time scale")
+	}
+	if sim.mdl == nil {
+		sim.createModel(nil)
+	}
+	sim.mdl.timeScale = scale
+	sim.mdl.resetWallClock()
+}
+
+// resetWallClock anchors the wall clock at the next wait
+func (mdl *model) resetWallClock() {
+	mdl.wallOrigin = time.Time{}
+}
+
+// anchorWallClock anchors the wall clock at the current simulation time if it is not anchored
+func (mdl *model) anchorWallClock() {
+	if mdl.wallOrigin.IsZero() {
+		mdl.wallOrigin = time.Now()
+		mdl.simOrigin = mdl.stime
+	}
+}
+
+// advanceToWallClock advances the simulation time to the current wall time but not after limit
+func (mdl *model) advanceToWallClock(limit float64) {
+	now := mdl.simOrigin + float64(time.Since(mdl.wallOrigin))/float64(mdl.timeScale)
+	if now > limit {
+		now = limit
+	}
+	if now > mdl.stime {
+		from := mdl.stime
+		mdl.stime = now
+		mdl.notifyTimeAdvance(from, now)
+	}
+}
+
+// waitWallClock sleeps until the wall time of the simulation time next.
+// It returns true if the sleep was interrupted by the injected functions;
+// the simulation time is advanced to the time of the injection and the functions are executed
+func (mdl *model) waitWallClock(next float64) bool {
+	if mdl.timeScale <= 0 {
+		return false
+	}
+	mdl.anchorWallClock()
+	target := mdl.wallOrigin.Add(time.Duration((next - mdl.simOrigin) * float64(mdl.timeScale)))
+	d := time.Until(target)
+	if d <= 0 {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return false
+	case <-mdl.wakeChannel:
+		mdl.advanceToWallClock(next)
+		mdl.drainMailbox()
+		return true
+	}
+}
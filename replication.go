@@ -0,0 +1,222 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// The replication runner executes independent replications of the model.
+// Before every replication the stream generators are reseeded, so
+// scenarios executed with the same number of runs use common random numbers
+// and can be compared with the paired differences.
+
+package godes
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Replication is a function which executes one replication of the model
+// and returns the values of the measures
+type Replication func(run int) []float64
+
+// Replicate executes the replication runs times and collects
+// the returned measures into StatCollector
+func Replicate(runs int, measures []string, replication Replication) *StatCollector {
+	if replication == nil {
+		panic("replication is nil")
+	}
+	if runs < 1 {
+		panic("invalid number of runs")
+	}
+	samples := [][]float64{}
+	for run := 0; run < runs; run++ {
+		samples = append(samples, runReplication(run, false, measures, replication))
+	}
+	SetReplication(0, false)
+	return NewStatCollector(measures, samples)
+}
+
+// ReplicateAntithetic executes runs pairs of antithetic replications.
+// The second replication of the pair uses antithetic values 1-U for every stream.
+// The average of the pair is collected as one observation
+func ReplicateAntithetic(runs int, measures []string, replication Replication) *StatCollector {
+	if replication == nil {
+		panic("replication is nil")
+	}
+	if runs < 1 {
+		panic("invalid number of runs")
+	}
+	samples := [][]float64{}
+	for run := 0; run < runs; run++ {
+		first := runReplication(run, false, measures, replication)
+		second := runReplication(run, true, measures, replication)
+		pair := make([]float64, len(measures))
+		for i := range pair {
+			pair[i] = (first[i] + second[i]) / 2.
+		}
+		samples = append(samples, pair)
+	}
+	SetReplication(0, false)
+	return NewStatCollector(measures, samples)
+}
+
+// ParallelReplication is a function which executes one replication of the model
+// on its own simulation instance and returns the values of the measures.
+// The model shall use the methods of the sim instead of the package-level functions
+// and create its generators with the stream constructors of the sim
+type ParallelReplication func(sim *Simulation, run int) []float64
+
+// ReplicateParallel executes runs replications concurrently with the number of workers
+// (the number of CPU cores if workers is zero). Each replication is executed on the new
+// simulation instance with the stream generators seeded for the run, so the results
+// are identical to the sequential run regardless of the number of workers.
+// The repeatable generators shall be created with sim.NewXxxDistr(true): they are seeded
+// in the order of creation within the range of the seeds of the run, while the order of
+// the package-level generators created by the concurrent replications is not repeatable
+func ReplicateParallel(runs int, measures []string, workers int, replication ParallelReplication) *StatCollector {
+	if replication == nil {
+		panic("replication is nil")
+	}
+	if runs < 1 {
+		panic("invalid number of runs")
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	samples := make([][]float64, runs)
+	runsChannel := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range runsChannel {
+				sim := newRunSimulation(run)
+				values := replication(sim, run)
+				if len(values) != len(measures) {
+					panic("invalid number of measures returned by replication")
+				}
+				samples[run] = values
+			}
+		}()
+	}
+	for run := 0; run < runs; run++ {
+		runsChannel <- run
+	}
+	close(runsChannel)
+	wg.Wait()
+	return NewStatCollector(measures, samples)
+}
+
+// newRunSimulation creates the simulation instance for the run of the parallel replications.
+// The stream generators are seeded for the run and the repeatable generators get the own range of the seeds
+func newRunSimulation(run int) *Simulation {
+	sim := NewSimulation()
+	sim.SetReplication(run, false)
+	sim.seedCount = sEED_COUNT + int64(run)*rUN_SEEDS
+	return sim
+}
+
+// Precision is the target precision of the measure with index Measure.
+// The target is reached when the half-width of the confidence interval is not greater
+// than Absolute or Relative multiplied by the absolute value of the average.
+// Zero Absolute or Relative target is not used
+type Precision struct {
+	Measure  int
+	Absolute float64
+	Relative float64
+}
+
+// ReplicateUntil executes the replications until the confidence intervals with the level
+// reach the target precisions for all the measures.
+// It executes at least minRuns and at most maxRuns replications. The second result is
+// true if the targets were reached; the number of executed replications is the size of StatCollector
+func ReplicateUntil(measures []string, replication Replication, targets []Precision, level float64, minRuns int, maxRuns int) (*StatCollector, bool) {
+	if replication == nil {
+		panic("replication is nil")
+	}
+	if len(targets) == 0 {
+		panic("no precision targets")
+	}
+	for _, target := range targets {
+		if target.Measure < 0 || target.Measure > len(measures)-1 {
+			panic("invalid index")
+		}
+		if target.Absolute <= 0 && target.Relative <= 0 {
+			panic("invalid precision target")
+		}
+	}
+	if minRuns < 2 || maxRuns < minRuns {
+		panic("invalid number of runs")
+	}
+	samples := [][]float64{}
+	var collector *StatCollector
+	reached := false
+	for run := 0; run < maxRuns; run++ {
+		samples = append(samples, runReplication(run, false, measures, replication))
+		if run+1 < minRuns {
+			continue
+		}
+		collector = NewStatCollector(measures, samples)
+		collector.SetConfidenceLevel(level)
+		if collector.reached(targets) {
+			reached = true
+			break
+		}
+	}
+	SetReplication(0, false)
+	return collector, reached
+}
+
+// reached returns true if all the precision targets are reached
+func (collector *StatCollector) reached(targets []Precision) bool {
+	for _, target := range targets {
+		hw := collector.GetHalfWidth(target.Measure)
+		ok := false
+		if target.Absolute > 0 && hw <= target.Absolute {
+			ok = true
+		}
+		if target.Relative > 0 && hw <= target.Relative*math.Abs(collector.GetAverage(target.Measure)) {
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// runReplication reseeds the streams and executes one replication
+func runReplication(run int, antithetic bool, measures []string, replication Replication) []float64 {
+	SetReplication(run, antithetic)
+	values := replication(run)
+	if len(values) != len(measures) {
+		panic("invalid number of measures returned by replication")
+	}
+	return values
+}
+
+// PairedDifferences returns StatCollector with the differences a-b
+// between the replications of two scenarios.
+// The scenarios shall be executed with the same number of runs
+func PairedDifferences(a *StatCollector, b *StatCollector) *StatCollector {
+	if a == nil || b == nil {
+		panic("collector is nil")
+	}
+	if len(a.measures) != len(b.measures) || len(a.samples) != len(b.samples) {
+		panic("collectors are not paired")
+	}
+	samples := [][]float64{}
+	for i := range a.samples {
+		row := make([]float64, len(a.measures))
+		for j := range row {
+			row[j] = a.samples[i][j] - b.samples[i][j]
+		}
+		samples = append(samples, row)
+	}
+	return NewStatCollector(a.measures, samples)
+}
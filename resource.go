@@ -0,0 +1,342 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Resource is the set of identical units (tellers, machines, operators).
+// The runner acquires a unit, works with it and releases it.
+// The capacity of the scheduled resource follows the Schedule and the failed units
+// (see Failure) are removed from the capacity; when the capacity
+// drops below the number of the busy units, the work in progress is handled
+// according to the policy:
+//	FinishWork - the work is completed, the units are removed when released
+//	PreemptWork - the work is stopped, the unit is lost and Work returns false
+//	WaitWork - the work is suspended and resumed with the remaining time when a unit is available
+
+package godes
+
+import (
+	"math"
+)
+
+// WIPPolicy defines what happens to the work in progress when the capacity drops
+type WIPPolicy int
+
+const (
+	FinishWork WIPPolicy = iota
+	PreemptWork
+	WaitWork
+)
+
+type resourceHolder struct {
+	runner      RunnerInterface
+	working     bool
+	suspended   bool
+	suspendedAt float64
+	preempted   bool
+}
+
+// Resource represents the set of identical units
+type Resource struct {
+	id       string
+	capacity int
+	// base is the fixed or scheduled capacity, down is the number of the failed units
+	base int
+	down int
+	busy int
+	// usage is the integral of the utilization over the simulation time
+	usage     float64
+	usageTime float64
+	policy    WIPPolicy
+	schedule  *Schedule
+	holders   []*resourceHolder
+	available *BooleanControl
+	failures  []*Failure
+	started   bool
+	sim       *Simulation
+}
+
+// NewResource creates the resource with the fixed capacity
+func NewResource(id string, capacity int) *Resource {
+	return defaultSimulation.newResource(id, capacity, nil, FinishWork)
+}
+
+// NewScheduledResource creates the resource with the capacity defined by the schedule
+func NewScheduledResource(id string, schedule *Schedule, policy WIPPolicy) *Resource {
+	return defaultSimulation.newResource(id, 0, schedule, policy)
+}
+
+// NewResource creates the resource of the simulation with the fixed capacity
+func (sim *Simulation) NewResource(id string, capacity int) *Resource {
+	return sim.newResource(id, capacity, nil, FinishWork)
+}
+
+// NewScheduledResource creates the resource of the simulation with the capacity defined by the schedule
+func (sim *Simulation) NewScheduledResource(id string, schedule *Schedule, policy WIPPolicy) *Resource {
+	return sim.newResource(id, 0, schedule, policy)
+}
+
+func (sim *Simulation) newResource(id string, capacity int, schedule *Schedule, policy WIPPolicy) *Resource {
+	if capacity < 0 {
+		panic("negative capacity")
+	}
+	r := &Resource{id: id, capacity: capacity, base: capacity, policy: policy, schedule: schedule, sim: sim}
+	r.available = sim.NewBooleanControl()
+	r.available.Set(capacity > 0)
+	return r
+}
+
+// SetPolicy sets the policy for the work in progress when the capacity drops
+func (r *Resource) SetPolicy(policy WIPPolicy) *Resource {
+	r.policy = policy
+	return r
+}
+
+// GetId returns the id of the resource
+func (r *Resource) GetId() string {
+	return r.id
+}
+
+// GetCapacity returns the current capacity, the failed units are not included
+func (r *Resource) GetCapacity() int {
+	return r.capacity
+}
+
+// GetFailed returns the number of the failed units
+func (r *Resource) GetFailed() int {
+	return r.down
+}
+
+// GetBusy returns the number of the busy units
+func (r *Resource) GetBusy() int {
+	return r.busy
+}
+
+// GetSuspended returns the number of the suspended works
+func (r *Resource) GetSuspended() int {
+	n := 0
+	for _, h := range r.holders {
+		if h.suspended {
+			n++
+		}
+	}
+	return n
+}
+
+// Acquire waits until the unit is available and acquires it for the runner.
+// The scheduled resource starts following its schedule and the failures
+// of the resource are started with the first call
+func (r *Resource) Acquire(runner RunnerInterface) {
+	r.acquire(runner)
+}
+
+// acquire waits for the unit of the resource.
+// It returns false if the runner is the daemon and no other runner is left
+func (r *Resource) acquire(runner RunnerInterface) bool {
+	if r.find(runner) != nil {
+		panic("resource is already acquired by the runner")
+	}
+	r.start()
+	r.refresh()
+	for !r.canAcquire() {
+		r.available.Wait(true)
+		if isDaemon(runner) && !getSimulation(r.sim).mdl.hasOtherRunners() {
+			return false
+		}
+		r.refresh()
+	}
+	r.accumulate()
+	r.holders = append(r.holders, &resourceHolder{runner: runner})
+	r.busy++
+	r.update()
+	return true
+}
+
+// Work holds the acquired unit for the duration.
+// It returns false if the work was preempted and the unit was lost;
+// the runner still calls Release and can Acquire the unit again
+func (r *Resource) Work(runner RunnerInterface, duration float64) bool {
+	h := r.find(runner)
+	if h == nil {
+		panic("resource is not acquired by the runner")
+	}
+	if h.preempted {
+		panic("work was preempted, the unit shall be released")
+	}
+	h.working = true
+	getSimulation(r.sim).Advance(duration)
+	h.working = false
+	return !h.preempted
+}
+
+// Release releases the unit acquired by the runner, the preempted unit is already lost
+func (r *Resource) Release(runner RunnerInterface) {
+	h := r.find(runner)
+	if h == nil {
+		panic("resource is not acquired by the runner")
+	}
+	r.remove(h)
+	r.accumulate()
+	if !h.suspended && !h.preempted {
+		r.busy--
+	}
+	r.refresh()
+	r.resumeSuspended()
+	r.update()
+}
+
+func (r *Resource) find(runner RunnerInterface) *resourceHolder {
+	for _, h := range r.holders {
+		if h.runner == runner {
+			return h
+		}
+	}
+	return nil
+}
+
+func (r *Resource) remove(h *resourceHolder) {
+	for i, x := range r.holders {
+		if x == h {
+			r.holders = append(r.holders[:i], r.holders[i+1:]...)
+			return
+		}
+	}
+}
+
+// canAcquire returns true if the unit is available and no suspended work waits for it
+func (r *Resource) canAcquire() bool {
+	return r.busy < r.capacity && r.GetSuspended() == 0
+}
+
+// update sets the availability and reschedules the usage-based failures
+func (r *Resource) update() {
+	r.available.Set(r.canAcquire())
+	for _, f := range r.failures {
+		f.reschedule()
+	}
+}
+
+// refresh applies the schedule before the schedule runner is activated
+// at the same simulation time
+func (r *Resource) refresh() {
+	if r.schedule == nil || !r.started {
+		return
+	}
+	capacity := r.schedule.GetCapacity(getSimulation(r.sim).GetSystemTime())
+	if capacity != r.base {
+		r.base = capacity
+		r.applyCapacity()
+	}
+}
+
+// accumulate updates the usage of the resource
+func (r *Resource) accumulate() {
+	now := getSimulation(r.sim).GetSystemTime()
+	r.usage += (now - r.usageTime) * r.utilization()
+	r.usageTime = now
+}
+
+// utilization returns the fraction of the busy units
+func (r *Resource) utilization() float64 {
+	if r.base <= 0 {
+		return 0
+	}
+	return math.Min(1, float64(r.busy)/float64(r.base))
+}
+
+// getUsage returns the integral of the utilization until the current time
+func (r *Resource) getUsage() float64 {
+	r.accumulate()
+	return r.usage
+}
+
+// applyCapacity changes the capacity and applies the policy to the work in progress
+func (r *Resource) applyCapacity() {
+	sim := getSimulation(r.sim)
+	now := sim.GetSystemTime()
+	r.accumulate()
+	r.capacity = r.base - r.down
+	if r.capacity < 0 {
+		r.capacity = 0
+	}
+	// the latest acquired units are taken first, the work finishing now is completed
+	for i := len(r.holders) - 1; i >= 0 && r.busy > r.capacity && r.policy != FinishWork; i-- {
+		h := r.holders[i]
+		if !h.working || h.suspended || h.preempted || h.runner.getState() != RunnerScheduled || h.runner.getMovingTime() <= now {
+			continue
+		}
+		sim.Interrupt(h.runner)
+		r.busy--
+		if r.policy == PreemptWork {
+			h.preempted = true
+			sim.Resume(h.runner, now-h.runner.getMovingTime())
+		} else {
+			h.suspended = true
+			h.suspendedAt = now
+		}
+	}
+	r.resumeSuspended()
+	r.update()
+}
+
+// resumeSuspended resumes the suspended works in the order of acquisition while the units are available
+func (r *Resource) resumeSuspended() {
+	sim := getSimulation(r.sim)
+	for _, h := range r.holders {
+		if r.busy >= r.capacity {
+			break
+		}
+		if h.suspended {
+			h.suspended = false
+			r.busy++
+			sim.Resume(h.runner, sim.GetSystemTime()-h.suspendedAt)
+		}
+	}
+}
+
+// start adds the runner which changes the capacity of the scheduled resource
+// and the runners of the failures
+func (r *Resource) start() {
+	if r.started {
+		return
+	}
+	r.started = true
+	for _, f := range r.failures {
+		f.start()
+	}
+	if r.schedule == nil {
+		return
+	}
+	r.base = r.schedule.GetCapacity(getSimulation(r.sim).GetSystemTime())
+	r.applyCapacity()
+	getSimulation(r.sim).AddRunner(&scheduleRunner{Runner: &Runner{}, resource: r})
+}
+
+// scheduleRunner changes the capacity of the resource at the times defined by the schedule.
+// It terminates when no other runner is left in the model
+type scheduleRunner struct {
+	*Runner
+	resource *Resource
+}
+
+func (s *scheduleRunner) daemon() {}
+
+func (s *scheduleRunner) Run() {
+	r := s.resource
+	sim := getSimulation(r.sim)
+	for {
+		now := sim.GetSystemTime()
+		next := r.schedule.NextChange(now)
+		if math.IsInf(next, 1) {
+			return
+		}
+		sim.Advance(next - now)
+		if !sim.mdl.hasOtherRunners() {
+			return
+		}
+		r.refresh()
+	}
+}
@@ -17,10 +17,44 @@ import (
 	"time"
 )
 
+// RunnerState is the state of the runner
+type RunnerState int
+
+const (
+	RunnerReady RunnerState = iota
+	RunnerActive
+	RunnerWaiting
+	RunnerScheduled
+	RunnerInterrupted
+	RunnerTerminated
+)
+
+var runnerStateNames = []string{
+	"READY",
+	"ACTIVE",
+	"WAITING_COND",
+	"SCHEDULED",
+	"INTERRUPTED",
+	"TERMINATED",
+}
+
+// String returns the name of the state
+func (state RunnerState) String() string {
+	if state < 0 || int(state) > len(runnerStateNames)-1 {
+		return "UNKNOWN"
+	}
+	return runnerStateNames[state]
+}
+
 type RunnerInterface interface {
 	Run()
-	setState(i int)
-	getState() int
+	ID() int
+	Name() string
+	Labels() map[string]string
+	State() RunnerState
+	ScheduledAt() (float64, bool)
+	setState(i RunnerState)
+	getState() RunnerState
 	setChannel(c chan int)
 	getChannel() chan int
 	setInternalId(id int)
@@ -40,7 +74,9 @@ type RunnerInterface interface {
 }
 
 type Runner struct {
-	state                          int
+	name                           string
+	labels                         map[string]string
+	state                          RunnerState
 	channel                        chan int
 	internalId                     int
 	movingTime                     float64
@@ -56,15 +92,16 @@ type TimeoutRunner struct {
 	*Runner
 	original      RunnerInterface
 	timeoutPeriod float64
+	mdl           *model
 }
 
 func (timeOut *TimeoutRunner) Run() {
-	Advance(timeOut.timeoutPeriod)
+	timeOut.mdl.advance(timeOut.timeoutPeriod)
 	if timeOut.original.getWaitingForBoolControl() != nil && timeOut.original.getWaitingForBoolControlTimeoutId() == timeOut.internalId {
-		timeOut.original.setState(rUNNER_STATE_READY)
+		timeOut.original.setState(RunnerReady)
 		timeOut.original.setWaitingForBoolControl(nil)
-		modl.addToMovingList(timeOut.original)
-		delete(modl.waitingConditionMap, timeOut.original.getInternalId())
+		timeOut.mdl.addToMovingList(timeOut.original)
+		delete(timeOut.mdl.waitingConditionMap, timeOut.original.getInternalId())
 	}
 
 }
@@ -77,11 +114,11 @@ func (b *Runner) Run() {
 	fmt.Println("Run Run Run Run")
 }
 
-func (b *Runner) setState(i int) {
+func (b *Runner) setState(i RunnerState) {
 	b.state = i
 }
 
-func (b *Runner) getState() int {
+func (b *Runner) getState() RunnerState {
 	return b.state
 }
 
@@ -153,40 +190,79 @@ func (b *Runner) getWaitingForBoolControlTimeoutId() int {
 }
 
 func (b *Runner) IsShedulled() bool {
-	if b.state == rUNNER_STATE_SCHEDULED {
+	if b.state == RunnerScheduled {
 		return true
 	}
 	return false
 }
 
 func (b *Runner) GetMovingTime() float64 {
-	if b.state == rUNNER_STATE_SCHEDULED {
+	if b.state == RunnerScheduled {
 		return b.movingTime
 	} else {
 		panic("Runner is Not Shedulled ")
 	}
 }
 
+// String returns the name, id, state and scheduled time of the runner
 func (b *Runner) String() string {
+	s := fmt.Sprintf("id=%v st=%v", b.internalId, b.state)
+	if b.name != "" {
+		s = b.name + " " + s
+	}
+	if at, ok := b.ScheduledAt(); ok {
+		s += fmt.Sprintf(" at=%v", at)
+	}
+	return s
+}
+
+// ID returns the id of the runner assigned when the runner is added into the model
+func (b *Runner) ID() int {
+	return b.internalId
+}
+
+// Name returns the name of the runner
+func (b *Runner) Name() string {
+	return b.name
+}
+
+// SetName sets the name of the runner
+func (b *Runner) SetName(name string) {
+	b.name = name
+}
+
+// Label returns the value of the runner label
+func (b *Runner) Label(key string) string {
+	return b.labels[key]
+}
+
+// SetLabel sets the runner label
+func (b *Runner) SetLabel(key string, value string) {
+	if b.labels == nil {
+		b.labels = make(map[string]string)
+	}
+	b.labels[key] = value
+}
+
+// Labels returns the copy of the runner labels
+func (b *Runner) Labels() map[string]string {
+	labels := make(map[string]string)
+	for k, v := range b.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// State returns the state of the runner
+func (b *Runner) State() RunnerState {
+	return b.state
+}
 
-	var st = ""
-
-	switch b.state {
-	case rUNNER_STATE_READY:
-		st = "READY"
-	case rUNNER_STATE_ACTIVE:
-		st = "ACTIVE"
-	case rUNNER_STATE_WAITING_COND:
-		st = "WAITING_COND"
-	case rUNNER_STATE_SCHEDULED:
-		st = "SCHEDULED"
-	case rUNNER_STATE_INTERRUPTED:
-		st = "INTERRUPTED"
-	case rUNNER_STATE_TERMINATED:
-		st = "TERMINATED"
-
-	default:
-		panic("Unknown state")
+// ScheduledAt returns the simulation time the scheduled runner will be activated at.
+// The interrupted runner has no activation time until it is resumed
+func (b *Runner) ScheduledAt() (float64, bool) {
+	if b.state == RunnerScheduled {
+		return b.movingTime, true
 	}
-	return fmt.Sprintf(" st=%v ch=%v id=%v mt=%v mk=%v pr=%v", st, b.channel, b.internalId, b.movingTime, b.markTime, b.priority)
+	return 0, false
 }
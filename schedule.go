@@ -0,0 +1,181 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Schedule defines the capacity over the simulation time: the shifts are repeated
+// with the period (e.g. one day or one week in the time unit of the simulation),
+// the exceptions (holidays, overtime) override the shifts for the absolute time intervals.
+// The capacity of the overlapping shifts is summed up, the capacity outside the shifts is zero.
+// The schedule drives the capacity of the Resource or the on/off state of the arrival source.
+
+package godes
+
+import (
+	"math"
+	"sort"
+)
+
+type scheduleInterval struct {
+	start    float64
+	end      float64
+	capacity int
+}
+
+// Schedule represents the shift calendar
+type Schedule struct {
+	period     float64
+	shifts     []scheduleInterval
+	exceptions []scheduleInterval
+}
+
+// NewSchedule creates the schedule repeated with the period.
+// Zero period means the schedule is not repeated
+func NewSchedule(period float64) *Schedule {
+	if period < 0 {
+		panic("negative period")
+	}
+	return &Schedule{period: period}
+}
+
+// AddShift adds the shift with the capacity from start until end.
+// For the periodic schedule the times are offsets within the period and
+// the shift with end before start continues in the next period (e.g. the night shift)
+func (s *Schedule) AddShift(start float64, end float64, capacity int) *Schedule {
+	if capacity < 0 {
+		panic("negative capacity")
+	}
+	if s.period > 0 {
+		if start < 0 || start >= s.period || end < 0 || end > s.period {
+			panic("shift is out of the period")
+		}
+		if end < start {
+			s.shifts = append(s.shifts, scheduleInterval{start, s.period, capacity}, scheduleInterval{0, end, capacity})
+			return s
+		}
+	}
+	if end <= start {
+		panic("invalid shift")
+	}
+	s.shifts = append(s.shifts, scheduleInterval{start, end, capacity})
+	return s
+}
+
+// AddException sets the capacity from the absolute simulation time start until end
+func (s *Schedule) AddException(start float64, end float64, capacity int) *Schedule {
+	if capacity < 0 {
+		panic("negative capacity")
+	}
+	if end <= start {
+		panic("invalid exception")
+	}
+	s.exceptions = append(s.exceptions, scheduleInterval{start, end, capacity})
+	return s
+}
+
+// GetCapacity returns the capacity at the simulation time t
+func (s *Schedule) GetCapacity(t float64) int {
+	for i := len(s.exceptions) - 1; i >= 0; i-- {
+		e := s.exceptions[i]
+		if t >= e.start && t < e.end {
+			return e.capacity
+		}
+	}
+	offset := t
+	if s.period > 0 {
+		offset = math.Mod(t, s.period)
+	}
+	capacity := 0
+	for _, shift := range s.shifts {
+		if offset >= shift.start && offset < shift.end {
+			capacity += shift.capacity
+		}
+	}
+	return capacity
+}
+
+// IsOpen returns true if the capacity at the simulation time t is positive
+func (s *Schedule) IsOpen(t float64) bool {
+	return s.GetCapacity(t) > 0
+}
+
+// NextChange returns the first time after t when the capacity changes, +Inf if never
+func (s *Schedule) NextChange(t float64) float64 {
+	current := s.GetCapacity(t)
+	for _, b := range s.boundaries(t) {
+		if s.GetCapacity(b) != current {
+			return b
+		}
+	}
+	return math.Inf(1)
+}
+
+// NextOpen returns the first time not before t when the capacity is positive, +Inf if never
+func (s *Schedule) NextOpen(t float64) float64 {
+	if s.IsOpen(t) {
+		return t
+	}
+	for _, b := range s.boundaries(t) {
+		if s.IsOpen(b) {
+			return b
+		}
+	}
+	return math.Inf(1)
+}
+
+// boundaries returns the sorted times after t when the capacity can change.
+// For the periodic schedule the boundaries of two periods ahead are enough,
+// since the exceptions are finite
+func (s *Schedule) boundaries(t float64) []float64 {
+	times := []float64{}
+	add := func(b float64) {
+		if b > t {
+			times = append(times, b)
+		}
+	}
+	last := t
+	for _, e := range s.exceptions {
+		add(e.start)
+		add(e.end)
+		last = math.Max(last, e.end)
+	}
+	if s.period > 0 {
+		first := math.Floor(t/s.period) * s.period
+		for base := first; base <= last+s.period; base += s.period {
+			for _, shift := range s.shifts {
+				add(base + shift.start)
+				add(base + shift.end)
+			}
+		}
+	} else {
+		for _, shift := range s.shifts {
+			add(shift.start)
+			add(shift.end)
+		}
+	}
+	sort.Float64s(times)
+	return times
+}
+
+// WaitOpen advances the calling runner until the schedule is open.
+// It returns false if the schedule will never be open; the arrival source uses it to stop
+func WaitOpen(s *Schedule) bool {
+	return defaultSimulation.WaitOpen(s)
+}
+
+// WaitOpen advances the calling runner of the simulation until the schedule is open.
+// It returns false if the schedule will never be open
+func (sim *Simulation) WaitOpen(s *Schedule) bool {
+	now := sim.GetSystemTime()
+	next := s.NextOpen(now)
+	if math.IsInf(next, 1) {
+		return false
+	}
+	if next > now {
+		sim.Advance(next - now)
+	}
+	return true
+}
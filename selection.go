@@ -0,0 +1,179 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// SelectBest implements the KN ranking and selection procedure (Kim and Nelson, 2001).
+// It selects the best of k scenarios with the probability of correct selection
+// not less than pcs, provided that the best scenario is better than the others by at
+// least delta (the indifference zone).
+// After the first stage of n0 replications, the scenarios are screened after every
+// additional replication and the scenarios which are clearly inferior are eliminated.
+// The replications of all the scenarios are executed with the common random numbers.
+
+package godes
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+)
+
+// Selection is the result of the ranking and selection procedure
+type Selection struct {
+	// Best is the index of the selected scenario
+	Best  int
+	Names []string
+	// Replications executed for each scenario
+	Replications []int
+	// Means of the measure for each scenario
+	Means []float64
+	// Eliminated is the number of replications after which the scenario was eliminated, zero for the best
+	Eliminated []int
+	// Truncated is true if the procedure was stopped by maxRuns
+	Truncated bool
+}
+
+// SelectBest selects the scenario with the best value of the measure with index measure.
+// If minimize is true, the smallest value is the best.
+// n0 is the number of the first stage replications (at least 2) and
+// maxRuns limits the number of replications for each scenario
+func SelectBest(names []string, scenarios []Replication, measure int, minimize bool, delta float64, pcs float64, n0 int, maxRuns int) *Selection {
+	k := len(scenarios)
+	if k < 2 || len(names) != k {
+		panic("invalid names/scenarios arrays")
+	}
+	if delta <= 0 {
+		panic("invalid indifference zone")
+	}
+	if pcs <= 1./float64(k) || pcs >= 1 {
+		panic("invalid probability of correct selection")
+	}
+	if n0 < 2 || maxRuns < n0 {
+		panic("invalid number of replications")
+	}
+	sign := 1.
+	if minimize {
+		sign = -1
+	}
+	samples := make([][]float64, k)
+	observe := func(i int, run int) {
+		SetReplication(run, false)
+		values := scenarios[i](run)
+		if measure < 0 || measure > len(values)-1 {
+			panic("invalid index")
+		}
+		samples[i] = append(samples[i], sign*values[measure])
+	}
+	for run := 0; run < n0; run++ {
+		for i := 0; i < k; i++ {
+			observe(i, run)
+		}
+	}
+	// first stage variances of the differences
+	alpha := 1 - pcs
+	eta := 0.5 * (math.Pow(2*alpha/float64(k-1), -2./float64(n0-1)) - 1)
+	h2 := 2 * eta * float64(n0-1)
+	s2 := make([][]float64, k)
+	for i := range s2 {
+		s2[i] = make([]float64, k)
+		for l := range s2[i] {
+			if l != i {
+				diff := make([]float64, n0)
+				for j := range diff {
+					diff[j] = samples[i][j] - samples[l][j]
+				}
+				sd := StandardDeviation(diff)
+				s2[i][l] = sd * sd
+			}
+		}
+	}
+	selection := &Selection{Best: -1, Names: names, Replications: make([]int, k), Means: make([]float64, k), Eliminated: make([]int, k)}
+	active := make([]bool, k)
+	for i := range active {
+		active[i] = true
+	}
+	count := k
+	run := n0
+	for {
+		means := make([]float64, k)
+		for i := range means {
+			if active[i] {
+				means[i] = Mean(samples[i])
+			}
+		}
+		eliminated := []int{}
+		for i := 0; i < k; i++ {
+			if !active[i] {
+				continue
+			}
+			for l := 0; l < k; l++ {
+				if l == i || !active[l] {
+					continue
+				}
+				w := math.Max(0, delta/(2*float64(run))*(h2*s2[i][l]/(delta*delta)-float64(run)))
+				if means[i] < means[l]-w {
+					eliminated = append(eliminated, i)
+					break
+				}
+			}
+		}
+		for _, i := range eliminated {
+			active[i] = false
+			selection.Eliminated[i] = run
+			count--
+		}
+		if count <= 1 || run >= maxRuns {
+			break
+		}
+		for i := 0; i < k; i++ {
+			if active[i] {
+				observe(i, run)
+			}
+		}
+		run++
+	}
+	SetReplication(0, false)
+	selection.Truncated = count > 1
+	for i := 0; i < k; i++ {
+		selection.Replications[i] = len(samples[i])
+		selection.Means[i] = sign * Mean(samples[i])
+		if active[i] && (selection.Best < 0 || sign*selection.Means[i] > sign*selection.Means[selection.Best]) {
+			selection.Best = i
+		}
+	}
+	if count == 0 {
+		// all the remaining scenarios were eliminated simultaneously
+		for i := 0; i < k; i++ {
+			if selection.Eliminated[i] == run && (selection.Best < 0 || sign*selection.Means[i] > sign*selection.Means[selection.Best]) {
+				selection.Best = i
+			}
+		}
+	}
+	selection.Eliminated[selection.Best] = 0
+	return selection
+}
+
+// PrintSelection prints the result of the ranking and selection procedure
+func (selection *Selection) PrintSelection() {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 10, 0, '\t', 0)
+	fmt.Fprintln(w, "Scenario\t#\tAverage\tStatus")
+	for i, name := range selection.Names {
+		status := fmt.Sprintf("eliminated at %d", selection.Eliminated[i])
+		if i == selection.Best {
+			status = "best"
+			if selection.Truncated {
+				status = "best (truncated)"
+			}
+		} else if selection.Eliminated[i] == 0 {
+			status = "not eliminated"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%6.3f\t%s\n", name, selection.Replications[i], selection.Means[i], status)
+	}
+	w.Flush()
+}
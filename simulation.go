@@ -0,0 +1,111 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Simulation is an independent instance of the simulation engine
+// with its own clock, runners and stream generators.
+// The package-level functions (Run, AddRunner, Advance, ...) operate on the default simulation.
+// The separate instances allow to execute several models concurrently
+// (see ReplicateParallel). The runners of the instance shall use the methods of
+// the instance instead of the package-level functions, and the queues, controls and
+// generators shall be created with the methods of the instance.
+
+package godes
+
+import (
+	"container/list"
+	"sort"
+)
+
+// Simulation is an independent instance of the simulation engine
+type Simulation struct {
+	mdl      *model
+	streams  streamSet
+	calendar calendar
+	// seedCount is the seed of the last repeatable generator
+	seedCount int64
+}
+
+var defaultSimulation = NewSimulation()
+
+// NewSimulation creates the simulation instance
+func NewSimulation() *Simulation {
+	return &Simulation{streams: streamSet{seed: streamSeed}, seedCount: sEED_COUNT}
+}
+
+// getSimulation returns the default simulation for nil
+func getSimulation(sim *Simulation) *Simulation {
+	if sim == nil {
+		return defaultSimulation
+	}
+	return sim
+}
+
+// RunnerInfo is the snapshot of the runner
+type RunnerInfo struct {
+	ID     int
+	Name   string
+	Labels map[string]string
+	State  RunnerState
+	// ScheduledAt is the activation time of the scheduled runner
+	ScheduledAt float64
+	Runner      RunnerInterface
+}
+
+// Runners returns the snapshot of the live runners of the model sorted by id
+func Runners() []RunnerInfo {
+	return defaultSimulation.Runners()
+}
+
+// Runners returns the snapshot of the live runners of the simulation sorted by id.
+// The internal timeout runners are not listed
+func (sim *Simulation) Runners() []RunnerInfo {
+	infos := []RunnerInfo{}
+	if sim.mdl == nil {
+		return infos
+	}
+	mdl := sim.mdl
+	seen := make(map[int]bool)
+	add := func(runner RunnerInterface) {
+		if runner == nil || runner.getInternalId() == 0 || seen[runner.getInternalId()] {
+			return
+		}
+		if _, ok := runner.(*TimeoutRunner); ok {
+			return
+		}
+		seen[runner.getInternalId()] = true
+		at, _ := runner.ScheduledAt()
+		infos = append(infos, RunnerInfo{
+			ID:          runner.ID(),
+			Name:        runner.Name(),
+			Labels:      runner.Labels(),
+			State:       runner.State(),
+			ScheduledAt: at,
+			Runner:      runner,
+		})
+	}
+	add(mdl.activeRunner)
+	add(mdl.next)
+	for _, l := range []*list.List{mdl.movingList, mdl.scheduledList} {
+		if l == nil {
+			continue
+		}
+		for e := l.Front(); e != nil; e = e.Next() {
+			add(e.Value.(RunnerInterface))
+		}
+	}
+	for _, runner := range mdl.waitingConditionMap {
+		add(runner)
+	}
+	for _, runner := range mdl.interruptedMap {
+		add(runner)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
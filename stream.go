@@ -0,0 +1,182 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Stream based generators are used for the common random numbers
+// and antithetic variates.
+// Each stream generator is identified by the stream number and is reseeded
+// for every replication (at the first draw after SetReplication), so the replication i of every scenario
+// receives exactly the same sequence of random numbers for each stream.
+// Stream generators use the inverse transform method, so the antithetic
+// replication (1-U) produces negatively correlated values.
+
+package godes
+
+import (
+	"math"
+	"math/rand"
+)
+
+var streamSeed int64 = 100000
+
+// streamSet keeps the replication of the stream generators of the simulation.
+// The generators are not registered: the generator created or reseeded in the older
+// epoch reseeds itself at the next draw
+type streamSet struct {
+	seed        int64
+	replication int
+	antithetic  bool
+	epoch       int
+}
+
+// SetStreamSeed changes the base seed of the stream generators of the new simulations
+// and reseeds the generators of the default simulation for the current replication.
+// It shall not be called while the replications are executed in parallel
+func SetStreamSeed(seed int64) {
+	streamSeed = seed
+	defaultSimulation.SetStreamSeed(seed)
+}
+
+// SetStreamSeed changes the base seed of the stream generators of the simulation
+// and reseeds them for the current replication
+func (sim *Simulation) SetStreamSeed(seed int64) {
+	set := &sim.streams
+	set.seed = seed
+	set.setReplication(set.replication, set.antithetic)
+}
+
+// GetStreamSeed returns the base seed of the stream generators of the simulation
+func (sim *Simulation) GetStreamSeed() int64 {
+	return sim.streams.seed
+}
+
+// SetReplication reseeds all the stream generators for the replication.
+// If antithetic flag is true, the generators produce antithetic values 1-U
+func SetReplication(replication int, antithetic bool) {
+	defaultSimulation.SetReplication(replication, antithetic)
+}
+
+// GetReplication returns the current replication and antithetic flag of the stream generators
+func GetReplication() (int, bool) {
+	return defaultSimulation.GetReplication()
+}
+
+// SetReplication reseeds the stream generators of the simulation for the replication
+func (sim *Simulation) SetReplication(replication int, antithetic bool) {
+	sim.streams.setReplication(replication, antithetic)
+}
+
+// GetReplication returns the current replication and antithetic flag of the stream generators of the simulation
+func (sim *Simulation) GetReplication() (int, bool) {
+	return sim.streams.replication, sim.streams.antithetic
+}
+
+func (set *streamSet) setReplication(replication int, antithetic bool) {
+	if replication < 0 {
+		panic("invalid replication")
+	}
+	set.replication = replication
+	set.antithetic = antithetic
+	set.epoch++
+}
+
+// StreamSeed returns the seed used by the stream in the replication with the base seed set by SetStreamSeed
+func StreamSeed(stream int, replication int) int64 {
+	return seedOf(streamSeed, stream, replication)
+}
+
+// seedOf returns the seed used by the stream in the replication with the base seed
+func seedOf(seed int64, stream int, replication int) int64 {
+	z := uint64(seed)
+	z = mix64(z + uint64(stream)*0x9e3779b97f4a7c15)
+	z = mix64(z + uint64(replication)*0xbf58476d1ce4e5b9)
+	return int64(z >> 1)
+}
+
+// mix64 is the splitmix64 finalizer
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// newDistribution creates the stream generator seeded for the current replication
+func (set *streamSet) newDistribution(stream int) distribution {
+	if stream < 0 {
+		panic("invalid stream")
+	}
+	d := distribution{stream: stream, inversion: true, set: set, epoch: set.epoch}
+	d.generator = rand.New(rand.NewSource(seedOf(set.seed, stream, set.replication)))
+	d.antithetic = set.antithetic
+	return d
+}
+
+// reseed seeds the stream generator for the current replication of the set
+func (d *distribution) reseed() {
+	d.generator.Seed(seedOf(d.set.seed, d.stream, d.set.replication))
+	d.antithetic = d.set.antithetic
+	d.epoch = d.set.epoch
+}
+
+// NewUniformDistrStream initiats the stream generator for the uniform distribution
+func NewUniformDistrStream(stream int) *UniformDistr {
+	return defaultSimulation.NewUniformDistrStream(stream)
+}
+
+// NewNormalDistrStream initiats the stream generator for the normal distribution
+func NewNormalDistrStream(stream int) *NormalDistr {
+	return defaultSimulation.NewNormalDistrStream(stream)
+}
+
+// NewExpDistrStream initiats the stream generator for the exponential distribution
+func NewExpDistrStream(stream int) *ExpDistr {
+	return defaultSimulation.NewExpDistrStream(stream)
+}
+
+// NewTriangularDistrStream initiats the stream generator for the triangular distribution
+func NewTriangularDistrStream(stream int) *TriangularDistr {
+	return defaultSimulation.NewTriangularDistrStream(stream)
+}
+
+// NewUniformDistrStream initiats the stream generator of the simulation for the uniform distribution
+func (sim *Simulation) NewUniformDistrStream(stream int) *UniformDistr {
+	b := &UniformDistr{sim.streams.newDistribution(stream)}
+	return b
+}
+
+// NewNormalDistrStream initiats the stream generator of the simulation for the normal distribution
+func (sim *Simulation) NewNormalDistrStream(stream int) *NormalDistr {
+	b := &NormalDistr{sim.streams.newDistribution(stream)}
+	return b
+}
+
+// NewExpDistrStream initiats the stream generator of the simulation for the exponential distribution
+func (sim *Simulation) NewExpDistrStream(stream int) *ExpDistr {
+	b := &ExpDistr{sim.streams.newDistribution(stream)}
+	return b
+}
+
+// NewTriangularDistrStream initiats the stream generator of the simulation for the triangular distribution
+func (sim *Simulation) NewTriangularDistrStream(stream int) *TriangularDistr {
+	b := &TriangularDistr{sim.streams.newDistribution(stream)}
+	return b
+}
+
+// normalInverse returns the standard normal quantile of u
+func normalInverse(u float64) float64 {
+	const eps = 1. / (1 << 53)
+	u = math.Max(eps, math.Min(u, 1-eps))
+	return math.Sqrt2 * math.Erfinv(2*u-1)
+}
+
+// expInverse returns the exponential (lambda=1) quantile of u
+func expInverse(u float64) float64 {
+	if u >= 1 {
+		u = math.Nextafter(1, 0)
+	}
+	return -math.Log(1 - u)
+}
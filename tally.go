@@ -0,0 +1,271 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Tally and TimeWeighted are the streaming statistics accumulators.
+// Tally collects the observations (e.g. waiting times) and
+// TimeWeighted collects the piecewise-constant state variables
+// (e.g. queue length or number of busy servers) weighted by the simulation time.
+// Both can be reset at the end of the warm-up period.
+
+package godes
+
+import (
+	"math"
+)
+
+// Tally is the streaming accumulator for observations.
+// Mean and variance are calculated with the Welford's algorithm
+type Tally struct {
+	id    string
+	count int64
+	mean  float64
+	m2    float64
+	sum   float64
+	min   float64
+	max   float64
+	// optional percentile estimators and histogram
+	quantiles []*P2Quantile
+	histogram *Histogram
+}
+
+// NewTally creates the Tally
+func NewTally(id string) *Tally {
+	t := &Tally{id: id}
+	t.Reset()
+	return t
+}
+
+// Add adds the observation
+func (t *Tally) Add(x float64) {
+	t.count++
+	delta := x - t.mean
+	t.mean += delta / float64(t.count)
+	t.m2 += delta * (x - t.mean)
+	t.sum += x
+	if x < t.min {
+		t.min = x
+	}
+	if x > t.max {
+		t.max = x
+	}
+	for _, q := range t.quantiles {
+		q.Add(x)
+	}
+	if t.histogram != nil {
+		t.histogram.Add(x)
+	}
+}
+
+// TrackPercentiles adds the streaming estimators of the percentiles p (0..1)
+func (t *Tally) TrackPercentiles(ps ...float64) {
+	for _, p := range ps {
+		t.quantiles = append(t.quantiles, NewP2Quantile(p))
+	}
+}
+
+// SetHistogram sets the histogram which receives the observations
+func (t *Tally) SetHistogram(h *Histogram) {
+	t.histogram = h
+}
+
+// GetHistogram returns the histogram of the tally
+func (t *Tally) GetHistogram() *Histogram {
+	return t.histogram
+}
+
+// GetPercentile returns the estimation of the tracked percentile p
+func (t *Tally) GetPercentile(p float64) float64 {
+	for _, q := range t.quantiles {
+		if q.GetP() == p {
+			return q.Get()
+		}
+	}
+	panic("percentile is not tracked")
+}
+
+// Reset removes all the observations
+func (t *Tally) Reset() {
+	t.count = 0
+	t.mean = 0
+	t.m2 = 0
+	t.sum = 0
+	t.min = math.Inf(1)
+	t.max = math.Inf(-1)
+	for _, q := range t.quantiles {
+		q.Reset()
+	}
+	if t.histogram != nil {
+		t.histogram.Reset()
+	}
+}
+
+// GetId returns the id of the tally
+func (t *Tally) GetId() string {
+	return t.id
+}
+
+// GetCount returns number of observations
+func (t *Tally) GetCount() int64 {
+	return t.count
+}
+
+// GetSum returns sum of observations
+func (t *Tally) GetSum() float64 {
+	return t.sum
+}
+
+// GetAverage returns average of observations
+func (t *Tally) GetAverage() float64 {
+	return t.mean
+}
+
+// GetVariance returns sample variance of observations
+func (t *Tally) GetVariance() float64 {
+	if t.count < 2 {
+		return 0
+	}
+	return t.m2 / float64(t.count-1)
+}
+
+// GetStandardDeviation returns sample standard deviation of observations
+func (t *Tally) GetStandardDeviation() float64 {
+	return math.Sqrt(t.GetVariance())
+}
+
+// GetMinimum returns minimum observation
+func (t *Tally) GetMinimum() float64 {
+	return t.min
+}
+
+// GetMaximum returns maximum observation
+func (t *Tally) GetMaximum() float64 {
+	return t.max
+}
+
+// TimeWeighted is the streaming accumulator for the piecewise-constant state variable.
+// The statistics are weighted by the time the variable keeps the value
+type TimeWeighted struct {
+	id        string
+	value     float64
+	lastTime  float64
+	startTime float64
+	area      float64
+	area2     float64
+	min       float64
+	max       float64
+	sim       *Simulation
+}
+
+// NewTimeWeighted creates the TimeWeighted with the initial value at the current simulation time
+func NewTimeWeighted(id string, initial float64) *TimeWeighted {
+	tw := &TimeWeighted{id: id, value: initial}
+	tw.Reset()
+	return tw
+}
+
+// NewTimeWeighted creates the TimeWeighted of the simulation
+func (sim *Simulation) NewTimeWeighted(id string, initial float64) *TimeWeighted {
+	tw := &TimeWeighted{id: id, value: initial, sim: sim}
+	tw.Reset()
+	return tw
+}
+
+// Set changes the value of the variable at the current simulation time
+func (tw *TimeWeighted) Set(value float64) {
+	tw.update()
+	tw.value = value
+	if value < tw.min {
+		tw.min = value
+	}
+	if value > tw.max {
+		tw.max = value
+	}
+}
+
+// Add increments the value of the variable by delta
+func (tw *TimeWeighted) Add(delta float64) {
+	tw.Set(tw.value + delta)
+}
+
+// Reset removes the history and starts collection at the current simulation time.
+// The current value is kept
+func (tw *TimeWeighted) Reset() {
+	stime := getSimulation(tw.sim).GetSystemTime()
+	tw.startTime = stime
+	tw.lastTime = stime
+	tw.area = 0
+	tw.area2 = 0
+	tw.min = tw.value
+	tw.max = tw.value
+}
+
+// update accumulates the area until the current simulation time
+func (tw *TimeWeighted) update() {
+	stime := getSimulation(tw.sim).GetSystemTime()
+	if stime < tw.lastTime {
+		// the simulation was cleared
+		tw.startTime = stime
+		tw.lastTime = stime
+		tw.area = 0
+		tw.area2 = 0
+	}
+	dt := stime - tw.lastTime
+	tw.area += tw.value * dt
+	tw.area2 += tw.value * tw.value * dt
+	tw.lastTime = stime
+}
+
+// GetId returns the id of the variable
+func (tw *TimeWeighted) GetId() string {
+	return tw.id
+}
+
+// GetValue returns the current value of the variable
+func (tw *TimeWeighted) GetValue() float64 {
+	return tw.value
+}
+
+// GetDuration returns the time elapsed since the start of collection
+func (tw *TimeWeighted) GetDuration() float64 {
+	tw.update()
+	return tw.lastTime - tw.startTime
+}
+
+// GetAverage returns time-weighted average until the current simulation time
+func (tw *TimeWeighted) GetAverage() float64 {
+	d := tw.GetDuration()
+	if d == 0 {
+		return tw.value
+	}
+	return tw.area / d
+}
+
+// GetVariance returns time-weighted variance until the current simulation time
+func (tw *TimeWeighted) GetVariance() float64 {
+	d := tw.GetDuration()
+	if d == 0 {
+		return 0
+	}
+	avg := tw.area / d
+	return math.Max(0, tw.area2/d-avg*avg)
+}
+
+// GetStandardDeviation returns time-weighted standard deviation until the current simulation time
+func (tw *TimeWeighted) GetStandardDeviation() float64 {
+	return math.Sqrt(tw.GetVariance())
+}
+
+// GetMinimum returns minimum value of the variable
+func (tw *TimeWeighted) GetMinimum() float64 {
+	return tw.min
+}
+
+// GetMaximum returns maximum value of the variable
+func (tw *TimeWeighted) GetMaximum() float64 {
+	return tw.max
+}
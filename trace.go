@@ -0,0 +1,272 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Trace is the input adaptor for the trace-driven simulation.
+// It reads the timestamped records from CSV or JSON Lines file
+// and replays them by creating a runner for each record
+// at the recorded simulation time.
+//
+// The record fields can be mapped into a struct with TraceRecord.Decode.
+// The struct fields are matched by the `trace:"name"` tag or by the field name.
+
+package godes
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TraceRecord is one timestamped record of the trace
+type TraceRecord struct {
+	Time   float64
+	Fields map[string]string
+}
+
+// Trace is the sequence of records sorted by time
+type Trace struct {
+	records []*TraceRecord
+}
+
+// LoadCSVTrace reads the trace from CSV file with the header line.
+// timeColumn is the name of the column with the simulation time
+func LoadCSVTrace(path string, timeColumn string) (*Trace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCSVTrace(f, timeColumn)
+}
+
+// LoadJSONLTrace reads the trace from JSON Lines file.
+// timeField is the name of the field with the simulation time
+func LoadJSONLTrace(path string, timeField string) (*Trace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadJSONLTrace(f, timeField)
+}
+
+// ReadCSVTrace reads the trace in CSV format with the header line
+func ReadCSVTrace(r io.Reader, timeColumn string) (*Trace, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("trace header: %v", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	trace := &Trace{}
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("trace line %d: %v", line, err)
+		}
+		fields := make(map[string]string)
+		for i, name := range header {
+			fields[name] = strings.TrimSpace(row[i])
+		}
+		if err := trace.add(fields, timeColumn); err != nil {
+			return nil, fmt.Errorf("trace line %d: %v", line, err)
+		}
+	}
+	trace.sort()
+	return trace, nil
+}
+
+// ReadJSONLTrace reads the trace in JSON Lines format.
+// Nested objects and arrays are kept as JSON text
+func ReadJSONLTrace(r io.Reader, timeField string) (*Trace, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	trace := &Trace{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return nil, fmt.Errorf("trace line %d: %v", line, err)
+		}
+		fields := make(map[string]string)
+		for name, raw := range obj {
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				fields[name] = s
+			} else if string(raw) != "null" {
+				fields[name] = string(raw)
+			}
+		}
+		if err := trace.add(fields, timeField); err != nil {
+			return nil, fmt.Errorf("trace line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	trace.sort()
+	return trace, nil
+}
+
+func (trace *Trace) add(fields map[string]string, timeField string) error {
+	value, ok := fields[timeField]
+	if !ok {
+		return fmt.Errorf("no time field %q", timeField)
+	}
+	t, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(t) || math.IsInf(t, 0) {
+		return fmt.Errorf("invalid time %q", value)
+	}
+	if t < 0 {
+		return fmt.Errorf("negative time %q", value)
+	}
+	trace.records = append(trace.records, &TraceRecord{Time: t, Fields: fields})
+	return nil
+}
+
+func (trace *Trace) sort() {
+	sort.SliceStable(trace.records, func(i, j int) bool {
+		return trace.records[i].Time < trace.records[j].Time
+	})
+}
+
+// Len returns number of records in the trace
+func (trace *Trace) Len() int {
+	return len(trace.records)
+}
+
+// GetRecord returns the record i
+func (trace *Trace) GetRecord(i int) *TraceRecord {
+	if i < 0 || i > len(trace.records)-1 {
+		panic("invalid index")
+	}
+	return trace.records[i]
+}
+
+// Replay creates the runner for each record and adds it into the model
+// at the recorded simulation time.
+// Like the arrival loop in the examples, it must be called
+// from the main goroutine after Run(). The records with the time in the past are
+// added at the current simulation time. The nil runners are skipped
+func (trace *Trace) Replay(create func(record *TraceRecord) RunnerInterface) {
+	defaultSimulation.ReplayTrace(trace, create)
+}
+
+// ReplayTrace replays the trace in the simulation
+func (sim *Simulation) ReplayTrace(trace *Trace, create func(record *TraceRecord) RunnerInterface) {
+	if create == nil {
+		panic("create is nil")
+	}
+	for _, record := range trace.records {
+		if record.Time > sim.GetSystemTime() {
+			sim.Advance(record.Time - sim.GetSystemTime())
+		}
+		runner := create(record)
+		if runner != nil {
+			sim.AddRunner(runner)
+		}
+	}
+}
+
+// Decode maps the record fields into the struct pointed by v
+func (record *TraceRecord) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode: pointer to struct expected")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get("trace")
+		if name == "-" {
+			continue
+		}
+		value, ok := record.lookup(name, field.Name)
+		if !ok {
+			continue
+		}
+		if err := setField(rv.Field(i), value); err != nil {
+			return fmt.Errorf("decode %s: %v", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func (record *TraceRecord) lookup(tag string, name string) (string, bool) {
+	if tag != "" {
+		value, ok := record.Fields[tag]
+		return value, ok
+	}
+	if value, ok := record.Fields[name]; ok {
+		return value, ok
+	}
+	for key, value := range record.Fields {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func setField(f reflect.Value, value string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(value, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(x)
+	default:
+		return fmt.Errorf("unsupported type %v", f.Type())
+	}
+	return nil
+}
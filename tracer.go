@@ -0,0 +1,187 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Tracer receives the typed events of the simulation engine:
+// the runner was added, activated, scheduled, is waiting on the control,
+// was interrupted, resumed or terminated.
+// The built-in tracers write the events into log/slog logger or
+// as JSON Lines. Verbose(true) sets the slog tracer with the default logger.
+
+package godes
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EventKind is the type of the engine event
+type EventKind int
+
+const (
+	EventRunnerAdded EventKind = iota
+	EventRunnerActivated
+	EventRunnerScheduled
+	EventRunnerWaiting
+	EventRunnerInterrupted
+	EventRunnerResumed
+	EventRunnerTerminated
+	EventSimulationFinished
+)
+
+var eventKindNames = []string{
+	"added",
+	"activated",
+	"scheduled",
+	"waiting",
+	"interrupted",
+	"resumed",
+	"terminated",
+	"finished",
+}
+
+// String returns the name of the event kind
+func (kind EventKind) String() string {
+	if kind < 0 || int(kind) > len(eventKindNames)-1 {
+		return "unknown"
+	}
+	return eventKindNames[kind]
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (kind EventKind) MarshalText() ([]byte, error) {
+	return []byte(kind.String()), nil
+}
+
+// Event is the engine event
+type Event struct {
+	Kind EventKind `json:"kind"`
+	// Time is the simulation time of the event
+	Time float64 `json:"time"`
+	// RunnerId is the id of the runner, 0 for the main goroutine
+	RunnerId int             `json:"runner"`
+	Runner   RunnerInterface `json:"-"`
+	// At is the time the runner is scheduled at, for scheduled and resumed events
+	At float64 `json:"at,omitempty"`
+	// WaitingFor is the state of the control the runner is waiting for, for waiting events
+	WaitingFor bool `json:"waitingFor,omitempty"`
+	// Calendar is the calendar time of the event if the calendar origin is set
+	Calendar *time.Time `json:"calendar,omitempty"`
+}
+
+// Tracer receives the engine events.
+// The events of one simulation are delivered sequentially
+type Tracer interface {
+	Trace(event Event)
+}
+
+// TracerFunc is the function implementing Tracer
+type TracerFunc func(event Event)
+
+// Trace implements Tracer
+func (f TracerFunc) Trace(event Event) {
+	f(event)
+}
+
+// SetTracer sets the tracer of the model, nil disables tracing
+func SetTracer(tracer Tracer) {
+	defaultSimulation.SetTracer(tracer)
+}
+
+// SetTracer sets the tracer of the simulation, nil disables tracing
+func (sim *Simulation) SetTracer(tracer Tracer) {
+	if sim.mdl == nil {
+		sim.createModel(nil)
+	}
+	sim.mdl.tracer = tracer
+}
+
+// trace sends the event of the runner to the tracer
+func (mdl *model) trace(kind EventKind, runner RunnerInterface) {
+	if mdl.tracer == nil {
+		return
+	}
+	event := Event{Kind: kind, Time: mdl.stime, Runner: runner}
+	if mdl.calendar != nil && mdl.calendar.originSet {
+		c := mdl.calendar.origin.Add(time.Duration(mdl.stime * float64(mdl.calendar.getUnit())))
+		event.Calendar = &c
+	}
+	if runner != nil {
+		event.RunnerId = runner.getInternalId()
+		switch kind {
+		case EventRunnerScheduled, EventRunnerResumed:
+			event.At = runner.getMovingTime()
+		case EventRunnerWaiting:
+			event.WaitingFor = runner.getWaitingForBool()
+		}
+	}
+	mdl.tracer.Trace(event)
+}
+
+type slogTracer struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// NewSlogTracer returns the tracer which writes the events into the logger with the level
+func NewSlogTracer(logger *slog.Logger, level slog.Level) Tracer {
+	if logger == nil {
+		panic("logger is nil")
+	}
+	return &slogTracer{logger: logger, level: level}
+}
+
+func (tracer *slogTracer) Trace(event Event) {
+	attrs := []slog.Attr{
+		slog.Float64("time", event.Time),
+		slog.Int("runner", event.RunnerId),
+	}
+	if event.Calendar != nil {
+		attrs = append(attrs, slog.Time("calendar", *event.Calendar))
+	}
+	switch event.Kind {
+	case EventRunnerScheduled, EventRunnerResumed:
+		attrs = append(attrs, slog.Float64("at", event.At))
+	case EventRunnerWaiting:
+		attrs = append(attrs, slog.Bool("waitingFor", event.WaitingFor))
+	}
+	tracer.logger.LogAttrs(context.Background(), tracer.level, event.Kind.String(), attrs...)
+}
+
+// JSONLTracer writes the events as JSON Lines
+type JSONLTracer struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+// NewJSONLTracer returns the tracer which writes the events as JSON Lines.
+// It can be shared by several simulations
+func NewJSONLTracer(w io.Writer) *JSONLTracer {
+	return &JSONLTracer{encoder: json.NewEncoder(w)}
+}
+
+// Trace implements Tracer. The events are not written after the first error
+func (tracer *JSONLTracer) Trace(event Event) {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if tracer.err != nil {
+		return
+	}
+	tracer.err = tracer.encoder.Encode(event)
+}
+
+// Err returns the first error of writing the events
+func (tracer *JSONLTracer) Err() error {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	return tracer.err
+}
@@ -13,11 +13,13 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"strconv"
 	"text/tabwriter"
 	"time"
 )
 const mAX_NUMBER_OF_SAMPLES = 100
 const mAX_NUMBER_OF_PARAMETERS = 6
+const dEFAULT_CONFIDENCE_LEVEL = 0.95
 
 var curTime int64
 
@@ -50,12 +52,67 @@ func NewStatCollector(measures []string, samples [][]float64) *StatCollector {
 		panic("invalid measures/samples arrays")
 	}
 
-	return &StatCollector{measures, samples}
+	return &StatCollector{measures: measures, samples: samples, level: dEFAULT_CONFIDENCE_LEVEL}
 }
 //StatCollector is a wrapper which contains set of samples for statistical analyses
 type StatCollector struct {
 	measures []string
 	samples  [][]float64
+	level    float64
+	// percentiles and histogram bins printed by PrintStat
+	percentiles []float64
+	bins        int
+}
+
+// SetPercentiles sets the percentiles (0..1) printed by PrintStat
+func (collector *StatCollector) SetPercentiles(ps ...float64) {
+	for _, p := range ps {
+		if p < 0 || p > 1 {
+			panic("invalid percentile")
+		}
+	}
+	collector.percentiles = append([]float64{}, ps...)
+}
+
+// SetHistograms sets number of bins of the text histograms printed by PrintStat.
+// Zero disables the histograms
+func (collector *StatCollector) SetHistograms(bins int) {
+	if bins < 0 {
+		panic("invalid number of bins")
+	}
+	collector.bins = bins
+}
+
+// GetPercentile returns the percentile p (0..1) of a sample
+func (collector *StatCollector) GetPercentile(measureInd int, p float64) float64 {
+	return Percentile(collector.getSlice(measureInd), p)
+}
+
+// GetHistogram returns the histogram of a sample
+func (collector *StatCollector) GetHistogram(measureInd int, bins int) *Histogram {
+	slice := collector.getSlice(measureInd)
+	min, max := MinMax(slice)
+	if max == min {
+		max = min + 1
+	}
+	h := NewHistogram(collector.measures[measureInd], min, math.Nextafter(max, math.Inf(1)), bins)
+	for _, x := range slice {
+		h.Add(x)
+	}
+	return h
+}
+
+// SetConfidenceLevel sets the confidence level (e.g. 0.99) of the confidence intervals
+func (collector *StatCollector) SetConfidenceLevel(level float64) {
+	if level <= 0 || level >= 1 {
+		panic("invalid confidence level")
+	}
+	collector.level = level
+}
+
+// GetConfidenceLevel returns the confidence level of the confidence intervals
+func (collector *StatCollector) GetConfidenceLevel() float64 {
+	return collector.level
 }
 
 //Print calculates statistical parameters and output them to *bufio.Writer
@@ -140,12 +197,27 @@ func (collector *StatCollector) Print(statWriter *bufio.Writer, measuresSwt bool
 func (collector *StatCollector) PrintStat() {
 	w := new(tabwriter.Writer)
 	w.Init(os.Stdout, 0, 10, 0, '\t', 0)
-	fmt.Fprintln(w, "Variable\t#\tAverage\tStd Dev\tL-Bound\tU-Bound\tMinimum\tMaximum")
+	fmt.Fprint(w, "Variable\t#\tAverage\tStd Dev\tL-Bound\tU-Bound\tMinimum\tMaximum\tHalf-W\tRel.Prec")
+	for _, p := range collector.percentiles {
+		// the percent is rounded, so 0.07 is printed as P7
+		fmt.Fprintf(w, "\tP%s", strconv.FormatFloat(math.Round(p*100*1e6)/1e6, 'f', -1, 64))
+	}
+	fmt.Fprintln(w)
 	for i := 0; i < len(collector.measures); i++ {
 		obs, avg, std, lb, ub, min, max := collector.GetStat(i)
-		fmt.Fprintf(w, "%s\t%d\t%6.3f\t%6.3f\t%6.3f\t%6.3f\t%6.3f\t%6.3f\n", collector.measures[i], obs, avg, std, lb, ub, min, max)
+		hw := (ub - lb) / 2
+		fmt.Fprintf(w, "%s\t%d\t%6.3f\t%6.3f\t%6.3f\t%6.3f\t%6.3f\t%6.3f\t%6.3f\t%6.3f", collector.measures[i], obs, avg, std, lb, ub, min, max, hw, relativePrecision(avg, hw))
+		for _, p := range collector.percentiles {
+			fmt.Fprintf(w, "\t%6.3f", collector.GetPercentile(i, p))
+		}
+		fmt.Fprintln(w)
 	}
 	w.Flush()
+	if collector.bins > 0 {
+		for i := 0; i < len(collector.measures); i++ {
+			collector.GetHistogram(i, collector.bins).PrintHistogram()
+		}
+	}
 	return
 }
 
@@ -165,12 +237,40 @@ func (collector *StatCollector) GetStat(measureInd int) (int64, float64, float64
 	}
 	avg = Mean(slice)
 	std = StandardDeviation(slice)
-	lb, ub = NormalConfidenceInterval(slice)
+	lb, ub = ConfidenceInterval(slice, collector.level)
 	min, max := MinMax(slice)
 	return repl, avg, std, lb, ub, min, max
 
 }
 
+//GetCI returns low and upper bounds of the Student's t confidence interval with the confidence level
+func (collector *StatCollector) GetCI(measureInd int, level float64) (float64, float64) {
+	return ConfidenceInterval(collector.getSlice(measureInd), level)
+}
+
+//GetHalfWidth returns half-width of confidence interval for sample
+func (collector *StatCollector) GetHalfWidth(measureInd int) float64 {
+	lb, ub := collector.GetCI(measureInd, collector.level)
+	return (ub - lb) / 2
+}
+
+//GetRelativePrecision returns half-width of confidence interval divided by the absolute value of average
+func (collector *StatCollector) GetRelativePrecision(measureInd int) float64 {
+	return relativePrecision(collector.GetAverage(measureInd), collector.GetHalfWidth(measureInd))
+}
+
+// getSlice returns the sample of the measure
+func (collector *StatCollector) getSlice(measureInd int) []float64 {
+	if measureInd < 0 || measureInd > len(collector.measures)-1 {
+		panic("invalid index")
+	}
+	slice := []float64{}
+	for i := 0; i < len(collector.samples); i++ {
+		slice = append(slice, collector.samples[i][measureInd])
+	}
+	return slice
+}
+
 //GetSize returns size of a sample
 func (collector *StatCollector) GetSize(measureInd int) int {
 	if measureInd < 0 || measureInd > len(collector.measures)-1 {
@@ -224,7 +324,7 @@ func (collector *StatCollector) GetLowBoundCI(measureInd int) float64 {
 	for i := 0; i < size; i++ {
 		slice = append(slice, collector.samples[i][measureInd])
 	}
-	lb, _ = NormalConfidenceInterval(slice)
+	lb, _ = ConfidenceInterval(slice, collector.level)
 	return lb
 }
 
@@ -240,7 +340,7 @@ func (collector *StatCollector) GetUpperBoundCI(measureInd int) float64 {
 	for i := 0; i < size; i++ {
 		slice = append(slice, collector.samples[i][measureInd])
 	}
-	_, ub = NormalConfidenceInterval(slice)
+	_, ub = ConfidenceInterval(slice, collector.level)
 	return ub
 }
 
@@ -302,7 +402,8 @@ func StandardDeviation(nums []float64) (dev float64) {
 	return dev
 }
 
-// NormalConfidenceInterval returns Confidence Interval
+// NormalConfidenceInterval returns 95% Confidence Interval based on the normal approximation.
+// For the small samples use ConfidenceInterval
 func NormalConfidenceInterval(nums []float64) (lower float64, upper float64) {
 	conf := 1.95996 // 95% confidence for the mean, http://bit.ly/Mm05eZ
 	mean := Mean(nums)
@@ -310,6 +411,28 @@ func NormalConfidenceInterval(nums []float64) (lower float64, upper float64) {
 	return mean - dev*conf, mean + dev*conf
 }
 
+// ConfidenceInterval returns Student's t confidence interval for the mean with the confidence level
+func ConfidenceInterval(nums []float64, level float64) (lower float64, upper float64) {
+	if level <= 0 || level >= 1 {
+		panic("invalid confidence level")
+	}
+	mean := Mean(nums)
+	if len(nums) < 2 {
+		return math.NaN(), math.NaN()
+	}
+	t := StudentTQuantile(1-(1-level)/2, float64(len(nums)-1))
+	dev := StandardDeviation(nums) / math.Sqrt(float64(len(nums)))
+	return mean - dev*t, mean + dev*t
+}
+
+// relativePrecision returns half-width divided by the absolute value of mean
+func relativePrecision(mean float64, halfWidth float64) float64 {
+	if mean == 0 {
+		return math.Inf(1)
+	}
+	return halfWidth / math.Abs(mean)
+}
+
 // MinMax returns minimum and maximum values amongst sample
 func MinMax(nums []float64) (minimum float64, maximum float64) {
 
@@ -0,0 +1,146 @@
+// Copyright 2015 Alex Goussiatiner. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+//
+// Godes  is the general-purpose simulation library
+// which includes the  simulation engine  and building blocks
+// for modeling a wide variety of systems at varying levels of details.
+//
+// Warm-up analysis tools detect the initial transient period of the run.
+// WelchAverages returns the data of the Welch's moving-average plot
+// calculated across the replications.
+// MSER5 returns the truncation point recommended by the MSER-5 heuristic.
+// The recommended warm-up time can be passed to ScheduleReset which resets
+// the statistics accumulators at the end of the warm-up period.
+
+package godes
+
+import (
+	"math"
+)
+
+// Resetter is implemented by the statistics accumulators
+// (Tally, TimeWeighted, Histogram, P2Quantile)
+type Resetter interface {
+	Reset()
+}
+
+// WelchAverages averages the series across the replications and
+// smooths the averages with the moving window of size 2*window+1.
+// The series are truncated to the shortest one
+func WelchAverages(replications [][]float64, window int) []float64 {
+	if len(replications) == 0 {
+		panic("no replications")
+	}
+	if window < 0 {
+		panic("invalid window")
+	}
+	m := len(replications[0])
+	for _, r := range replications {
+		if len(r) < m {
+			m = len(r)
+		}
+	}
+	avg := make([]float64, m)
+	for _, r := range replications {
+		for i := 0; i < m; i++ {
+			avg[i] += r[i] / float64(len(replications))
+		}
+	}
+	if m-window < 1 {
+		panic("window is too large")
+	}
+	moving := make([]float64, m-window)
+	for i := range moving {
+		w := window
+		if i < window {
+			w = i
+		}
+		moving[i] = Mean(avg[i-w : i+w+1])
+	}
+	return moving
+}
+
+// MSER returns the truncation point (number of observations to delete) which
+// minimizes the marginal standard error of the batch means with the batch size.
+// The truncation is searched within the first half of the series
+func MSER(series []float64, batchSize int) int {
+	if batchSize < 1 {
+		panic("invalid batch size")
+	}
+	if len(series)/batchSize < 2 {
+		panic("series is too short for MSER")
+	}
+	means := BatchMeans(series[:len(series)-len(series)%batchSize], len(series)/batchSize)
+	k := len(means)
+	best := 0
+	bestStat := math.Inf(1)
+	for d := 0; d <= k/2; d++ {
+		rest := means[d:]
+		mean := Mean(rest)
+		ss := 0.
+		for _, x := range rest {
+			ss += (x - mean) * (x - mean)
+		}
+		n := float64(len(rest))
+		stat := ss / (n * n)
+		if stat < bestStat {
+			bestStat = stat
+			best = d
+		}
+	}
+	return best * batchSize
+}
+
+// MSER5 returns the truncation point recommended by the MSER-5 heuristic
+func MSER5(series []float64) int {
+	return MSER(series, 5)
+}
+
+// MSER5Time returns the warm-up time recommended by the MSER-5 heuristic
+// for the series observed at the times
+func MSER5Time(times []float64, series []float64) float64 {
+	if len(times) != len(series) {
+		panic("invalid times/series arrays")
+	}
+	d := MSER5(series)
+	if d == 0 {
+		return 0
+	}
+	return times[d-1]
+}
+
+// resetRunner resets the accumulators at the scheduled time.
+// It terminates without the reset when no other runner is left in the model
+type resetRunner struct {
+	*Runner
+	sim   *Simulation
+	delay float64
+	items []Resetter
+}
+
+func (r *resetRunner) daemon() {}
+
+func (r *resetRunner) Run() {
+	r.sim.Advance(r.delay)
+	if !r.sim.mdl.hasOtherRunners() {
+		return
+	}
+	for _, item := range r.items {
+		item.Reset()
+	}
+}
+
+// ScheduleReset resets the accumulators at the simulation time warmup,
+// e.g. the time recommended by MSER5Time. It must be called after Run()
+func ScheduleReset(warmup float64, items ...Resetter) {
+	defaultSimulation.ScheduleReset(warmup, items...)
+}
+
+// ScheduleReset resets the accumulators at the simulation time warmup
+func (sim *Simulation) ScheduleReset(warmup float64, items ...Resetter) {
+	if warmup < sim.GetSystemTime() {
+		panic("warm-up time is in the past")
+	}
+	sim.AddRunner(&resetRunner{&Runner{}, sim, warmup - sim.GetSystemTime(), items})
+}